@@ -0,0 +1,339 @@
+// Package backup saves a whole slab organization to a local directory: the content and metadata of every post, the
+// topic tree and the users.
+//
+//	b := &backup.Backup{Client: client, Dir: "slab-backup"}
+//	m, err := b.Run(ctx)
+//
+// The directory is laid out as follows:
+//
+//	manifest.json      the Manifest, listing the posts backed up
+//	organization.json  the id, name and host of the organization
+//	topics.json        the topics, with their parent and their posts, as returned by TopicService.ListWithPosts
+//	users.json         the users, as returned by UserService.List
+//	posts/<id>.json    every post, with its content, as returned by PostService.Get
+//	posts/<id>.md      the content of every post as markdown, with Backup.Markdown
+//
+// The manifest is saved regularly while the posts are fetched. When a backup is interrupted, running it again in the
+// same directory only fetches the posts it is missing, and forgets the ones deleted from the organization since.
+// Once complete, the directory can be packed with WriteArchive.
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/VEVO/slab-go/slab"
+)
+
+// ErrComplete is returned by Run when the directory already holds a complete backup, which is never overwritten,
+// and there is no Backup.Archive to write.
+var ErrComplete = errors.New("backup: the directory already holds a complete backup")
+
+// DefaultConcurrency is the number of posts fetched at the same time when Backup.Concurrency is zero.
+const DefaultConcurrency = 4
+
+// saveEvery is the number of posts fetched between two saves of the manifest.
+const saveEvery = 20
+
+// Backup saves an organization to a directory. Only Client and Dir are required.
+type Backup struct {
+	Client *slab.Client
+	// Dir is the directory the backup is written to. It is created when missing.
+	Dir string
+	// Concurrency is the maximum number of posts fetched at the same time, DefaultConcurrency when zero.
+	Concurrency int
+	// Markdown makes the content of the posts also saved as markdown, next to their JSON file.
+	Markdown bool
+	// Archive, when set, is the path of a tar.gz archive of the directory written once the backup is complete. When
+	// the directory already holds a complete backup, Run only writes the archive, so that a failed archive can be
+	// retried.
+	Archive string
+	// Logf, when set, is called to report the progress of the backup.
+	Logf func(format string, args ...interface{})
+}
+
+// organizationInfo is the content of organization.json.
+type organizationInfo struct {
+	ID         string         `json:"id"`
+	Host       string         `json:"host"`
+	Name       string         `json:"name"`
+	InsertedAt *slab.DateTime `json:"insertedAt,omitempty"`
+	UpdatedAt  *slab.DateTime `json:"updatedAt,omitempty"`
+}
+
+// Run backs up the organization, resuming the backup left in Dir by an interrupted run if any. The topics and users
+// are fetched again on every run, the posts already recorded in the manifest are not, and the recorded posts which
+// are not in the organization anymore are removed from the manifest and the directory. The posts which disappear
+// between the listing and their fetch are recorded in Manifest.Missing. Any other error stops the backup, after the
+// manifest is saved so that the next run resumes it.
+func (b *Backup) Run(ctx context.Context) (*Manifest, error) {
+	if b.Dir == "" {
+		return nil, errors.New("backup: Dir is required")
+	}
+	if err := os.MkdirAll(filepath.Join(b.Dir, "posts"), 0755); err != nil {
+		return nil, err
+	}
+	m, err := LoadManifest(b.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if m.CompletedAt != nil {
+		if b.Archive == "" {
+			return nil, fmt.Errorf("%w: %s", ErrComplete, b.Dir)
+		}
+		return m, b.archive()
+	}
+
+	org, err := b.Client.Organization.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, fmt.Errorf("%w: organization", slab.ErrNotFound)
+	}
+	if m.OrganizationID != "" && m.OrganizationID != org.ID {
+		return nil, fmt.Errorf("backup: %s holds a backup of organization %s, not %s", b.Dir, m.OrganizationID, org.ID)
+	}
+	if len(m.Posts) > 0 {
+		b.logf("resuming the backup of %s started at %s", b.Dir, m.StartedAt.Format(time.RFC3339))
+	}
+	m.OrganizationID, m.OrganizationName, m.Host = org.ID, org.Name, org.Host
+	err = b.writeJSON("organization.json", &organizationInfo{
+		ID: org.ID, Host: org.Host, Name: org.Name, InsertedAt: org.InsertedAt, UpdatedAt: org.UpdatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	topics, err := b.Client.Topic.ListWithPostsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if topics == nil {
+		topics = &[]slab.Topic{}
+	}
+	if err := b.writeJSON("topics.json", topics); err != nil {
+		return nil, err
+	}
+	m.Topics = len(*topics)
+	b.logf("backed up %d topics", m.Topics)
+
+	users, err := b.Client.User.ListContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		users = &[]slab.User{}
+	}
+	if err := b.writeJSON("users.json", users); err != nil {
+		return nil, err
+	}
+	m.Users = len(*users)
+	b.logf("backed up %d users", m.Users)
+
+	var pending []string
+	listed := make(map[string]bool)
+	if org.Posts != nil {
+		for _, p := range *org.Posts {
+			listed[p.ID] = true
+			if !b.backedUp(m, p.ID) {
+				pending = append(pending, p.ID)
+			}
+		}
+	}
+	for _, id := range m.PostIDs() {
+		if !listed[id] {
+			if err := b.removePost(m, id); err != nil {
+				return nil, err
+			}
+			b.logf("post %s was deleted from the organization, removed it", id)
+		}
+	}
+	m.Missing = nil
+	if err := m.Save(b.Dir); err != nil {
+		return nil, err
+	}
+	b.logf("backing up %d posts", len(pending))
+	err = b.fetchPosts(ctx, m, pending)
+	if saveErr := m.Save(b.Dir); err == nil {
+		err = saveErr
+	}
+	if err != nil {
+		return m, err
+	}
+
+	completed := time.Now().UTC()
+	m.CompletedAt = &completed
+	if err := m.Save(b.Dir); err != nil {
+		return m, err
+	}
+	b.logf("backed up %d posts to %s", len(m.Posts), b.Dir)
+	if b.Archive != "" {
+		return m, b.archive()
+	}
+	return m, nil
+}
+
+// archive writes the archive of the complete backup to Archive.
+func (b *Backup) archive() error {
+	if err := writeArchiveFile(b.Archive, b.Dir); err != nil {
+		return err
+	}
+	b.logf("archived %s to %s", b.Dir, b.Archive)
+	return nil
+}
+
+// removePost removes the files of a post from the directory and its entry from the manifest.
+func (b *Backup) removePost(m *Manifest, id string) error {
+	name := filepath.Join(b.Dir, "posts", url.PathEscape(id))
+	for _, filename := range []string{name + ".json", name + ".md"} {
+		if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	delete(m.Posts, id)
+	return nil
+}
+
+// backedUp tells whether the post was recorded in the manifest and its file is still intact.
+func (b *Backup) backedUp(m *Manifest, id string) bool {
+	e, ok := m.Posts[id]
+	if !ok {
+		return false
+	}
+	content, err := ioutil.ReadFile(filepath.Join(b.Dir, filepath.FromSlash(e.File)))
+	return err == nil && hashContent(content) == e.Hash
+}
+
+// fetchPosts backs up the posts of the given ids, with at most Concurrency requests at the same time. The first error
+// cancels the other fetches.
+func (b *Backup) fetchPosts(ctx context.Context, m *Manifest, ids []string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		fetched  int
+	)
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < b.concurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				e, err := b.backupPost(ctx, id)
+				mu.Lock()
+				switch {
+				case slab.IsNotFound(err):
+					m.Missing = append(m.Missing, id)
+					b.logf("post %s disappeared, skipping it", id)
+				case err != nil:
+					fail(fmt.Errorf("backup: post %s: %w", id, err))
+				default:
+					m.Posts[id] = e
+					fetched++
+					b.logf("backed up post %s (%d/%d)", id, fetched, len(ids))
+					if fetched%saveEvery == 0 {
+						if err := m.Save(b.Dir); err != nil {
+							fail(err)
+						}
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, id := range ids {
+		select {
+		case jobs <- id:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	// Interrupted between two fetches
+	return ctx.Err()
+}
+
+// backupPost fetches a post and writes its files.
+func (b *Backup) backupPost(ctx context.Context, id string) (*PostEntry, error) {
+	p, err := b.Client.Post.GetContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, fmt.Errorf("%w: post %s", slab.ErrNotFound, id)
+	}
+	name := path.Join("posts", url.PathEscape(id))
+	if b.Markdown && p.Content != nil {
+		d, err := p.ParseContent()
+		if err != nil {
+			return nil, err
+		}
+		if err := b.writeFile(name+".md", []byte(d.Markdown())); err != nil {
+			return nil, err
+		}
+	}
+	content, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	content = append(content, '\n')
+	if err := b.writeFile(name+".json", content); err != nil {
+		return nil, err
+	}
+	return &PostEntry{
+		Title: p.Title, Version: p.Version, File: name + ".json", Hash: hashContent(content), BackedUpAt: time.Now().UTC(),
+	}, nil
+}
+
+// writeJSON writes v as indented JSON to the file of the given name, relative to Dir and using forward slashes.
+func (b *Backup) writeJSON(name string, v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return b.writeFile(name, append(content, '\n'))
+}
+
+// writeFile writes content to the file of the given name, relative to Dir and using forward slashes.
+func (b *Backup) writeFile(name string, content []byte) error {
+	return writeFileAtomic(filepath.Join(b.Dir, filepath.FromSlash(name)), content)
+}
+
+func (b *Backup) concurrency() int {
+	if b.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return b.Concurrency
+}
+
+func (b *Backup) logf(format string, args ...interface{}) {
+	if b.Logf != nil {
+		b.Logf(format, args...)
+	}
+}
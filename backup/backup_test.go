@@ -0,0 +1,446 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VEVO/slab-go/internal/slabtest"
+	"github.com/VEVO/slab-go/slab"
+	"github.com/stretchr/testify/assert"
+)
+
+// newServer returns a fake slab API holding 3 posts, 2 topics and 2 users, and the ids of the posts.
+func newServer() (*slabtest.Server, []string) {
+	srv := slabtest.NewServer()
+	eng := srv.AddTopic("Engineering", "")
+	guides := srv.AddTopic("Guides", eng)
+	ids := []string{
+		srv.AddPost(slabtest.Post{Title: "Welcome", Version: 2, Content: `[{"insert":"Welcome"},{"insert":"\n","attributes":{"header":1}}]`}),
+		srv.AddPost(slabtest.Post{Title: "Setup", Version: 1, Content: `[{"insert":"Setup\n"}]`, Topics: []string{guides}}),
+		srv.AddPost(slabtest.Post{Title: "Deploy", Version: 5, Content: `[{"insert":"Deploy\n"}]`, Topics: []string{eng, guides}}),
+	}
+	srv.Users = []slab.User{{ID: "user1", Name: "Ada"}, {ID: "user2", Name: "Grace"}}
+	return srv, ids
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// transport sends the requests to the default transport, after calling before when set.
+type transport struct {
+	before func(req *http.Request, body []byte) (*http.Response, error)
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if t.before != nil {
+		if resp, err := t.before(req, body); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestBackup_Run(t *testing.T) {
+	srv, ids := newServer()
+	defer srv.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := &Backup{Client: srv.Client(), Dir: dir, Markdown: true}
+	m, err := b.Run(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "org1", m.OrganizationID)
+	assert.Equal(t, "Test org", m.OrganizationName)
+	assert.Equal(t, "test.slab.com", m.Host)
+	assert.Equal(t, 2, m.Topics)
+	assert.Equal(t, 2, m.Users)
+	assert.NotNil(t, m.CompletedAt)
+	assert.Empty(t, m.Missing)
+	assert.Equal(t, ids, m.PostIDs())
+	e := m.Posts[ids[0]]
+	assert.Equal(t, "Welcome", e.Title)
+	assert.Equal(t, 2, e.Version)
+	assert.Equal(t, "posts/"+ids[0]+".json", e.File)
+
+	saved, err := LoadManifest(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, m.PostIDs(), saved.PostIDs())
+	assert.NotNil(t, saved.CompletedAt)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "posts", ids[0]+".json"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, e.Hash, hashContent(content))
+		var p slab.Post
+		assert.NoError(t, json.Unmarshal(content, &p))
+		assert.Equal(t, "Welcome", p.Title)
+		if assert.NotNil(t, p.Content) {
+			assert.Equal(t, `[{"insert":"Welcome"},{"insert":"\n","attributes":{"header":1}}]`, *p.Content)
+		}
+	}
+	md, err := ioutil.ReadFile(filepath.Join(dir, "posts", ids[0]+".md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "# Welcome\n", string(md))
+
+	var topics []slab.Topic
+	content, _ = ioutil.ReadFile(filepath.Join(dir, "topics.json"))
+	assert.NoError(t, json.Unmarshal(content, &topics))
+	if assert.Len(t, topics, 2) {
+		assert.Equal(t, "Guides", topics[1].Name)
+		assert.Equal(t, topics[0].ID, topics[1].Parent.ID)
+		assert.Len(t, *topics[1].Posts, 2)
+	}
+	var users []slab.User
+	content, _ = ioutil.ReadFile(filepath.Join(dir, "users.json"))
+	assert.NoError(t, json.Unmarshal(content, &users))
+	assert.Equal(t, srv.Users, users)
+	var org map[string]interface{}
+	content, _ = ioutil.ReadFile(filepath.Join(dir, "organization.json"))
+	assert.NoError(t, json.Unmarshal(content, &org))
+	assert.Equal(t, map[string]interface{}{"id": "org1", "name": "Test org", "host": "test.slab.com"}, org)
+
+	// A complete backup is never overwritten
+	_, err = b.Run(context.Background())
+	assert.True(t, errors.Is(err, ErrComplete), "%v", err)
+}
+
+func TestBackup_Run_NullLists(t *testing.T) {
+	srv, ids := newServer()
+	defer srv.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// The topics and users are listed as null, the organization and the posts are served by the fake
+	client := slab.NewClient(&http.Client{Transport: &transport{
+		before: func(req *http.Request, body []byte) (*http.Response, error) {
+			var data string
+			switch {
+			case bytes.Contains(body, []byte("hierarchy")):
+				data = `{"data":{"organization":{"topics":null}}}`
+			case bytes.Contains(body, []byte("deactivatedAt")):
+				data = `{"data":{"organization":{"users":null}}}`
+			default:
+				return nil, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(data)),
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Request:    req,
+			}, nil
+		},
+	}}, "dummy_token", slab.WithEndpoint(srv.URL))
+
+	m, err := (&Backup{Client: client, Dir: dir}).Run(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Zero(t, m.Topics)
+	assert.Zero(t, m.Users)
+	assert.Equal(t, ids, m.PostIDs())
+	content, _ := ioutil.ReadFile(filepath.Join(dir, "users.json"))
+	assert.Equal(t, "[]\n", string(content))
+}
+
+func TestBackup_Resume(t *testing.T) {
+	srv, ids := newServer()
+	defer srv.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := &Backup{Client: srv.Client(), Dir: dir, Concurrency: 1, Logf: func(format string, args ...interface{}) {
+		if strings.HasPrefix(format, "backed up post") {
+			cancel()
+		}
+	}}
+	_, err := b.Run(ctx)
+	assert.True(t, errors.Is(err, context.Canceled), "%v", err)
+
+	m, err := LoadManifest(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Nil(t, m.CompletedAt)
+	assert.Equal(t, []string{ids[0]}, m.PostIDs())
+
+	srv.Mu.Lock()
+	calls := srv.Calls["post"]
+	srv.Mu.Unlock()
+	b.Logf = nil
+	m, err = b.Run(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotNil(t, m.CompletedAt)
+	assert.Equal(t, ids, m.PostIDs())
+	srv.Mu.Lock()
+	assert.Equal(t, calls+2, srv.Calls["post"], "only the missing posts are fetched")
+	srv.Mu.Unlock()
+}
+
+func TestBackup_Resume_DeletedPost(t *testing.T) {
+	srv, ids := newServer()
+	defer srv.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := &Backup{Client: srv.Client(), Dir: dir, Concurrency: 1, Markdown: true, Logf: func(format string, args ...interface{}) {
+		if strings.HasPrefix(format, "backed up post") {
+			cancel()
+		}
+	}}
+	_, err := b.Run(ctx)
+	assert.True(t, errors.Is(err, context.Canceled), "%v", err)
+
+	// The post backed up by the interrupted run is deleted before the backup is resumed
+	srv.Mu.Lock()
+	delete(srv.Posts, ids[0])
+	srv.Mu.Unlock()
+	b.Logf = nil
+	m, err := b.Run(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, ids[1:], m.PostIDs())
+	assert.Empty(t, m.Missing)
+	for _, ext := range []string{".json", ".md"} {
+		_, err = os.Stat(filepath.Join(dir, "posts", ids[0]+ext))
+		assert.True(t, os.IsNotExist(err), "%v", err)
+	}
+}
+
+func TestBackup_Resume_CorruptedFile(t *testing.T) {
+	srv, ids := newServer()
+	defer srv.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := &Backup{Client: srv.Client(), Dir: dir}
+	m, err := b.Run(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	// Simulate a backup interrupted after a file was damaged
+	m.CompletedAt = nil
+	assert.NoError(t, m.Save(dir))
+	filename := filepath.Join(dir, "posts", ids[1]+".json")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("{"), 0644))
+
+	srv.Mu.Lock()
+	calls := srv.Calls["post"]
+	srv.Mu.Unlock()
+	_, err = b.Run(context.Background())
+	assert.NoError(t, err)
+	srv.Mu.Lock()
+	assert.Equal(t, calls+1, srv.Calls["post"])
+	srv.Mu.Unlock()
+	content, _ := ioutil.ReadFile(filename)
+	assert.Contains(t, string(content), `"title": "Setup"`)
+}
+
+func TestBackup_Run_Missing(t *testing.T) {
+	srv, ids := newServer()
+	defer srv.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := &Backup{Client: srv.Client(), Dir: dir, Logf: func(format string, args ...interface{}) {
+		// Deleted between the listing and the fetch
+		if strings.HasPrefix(format, "backing up") {
+			srv.Mu.Lock()
+			delete(srv.Posts, ids[1])
+			srv.Mu.Unlock()
+		}
+	}}
+	m, err := b.Run(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotNil(t, m.CompletedAt)
+	assert.Equal(t, []string{ids[1]}, m.Missing)
+	assert.Equal(t, []string{ids[0], ids[2]}, m.PostIDs())
+}
+
+func TestBackup_Run_Error(t *testing.T) {
+	srv, ids := newServer()
+	defer srv.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	client := slab.NewClient(&http.Client{Transport: &transport{
+		before: func(req *http.Request, body []byte) (*http.Response, error) {
+			if bytes.Contains(body, []byte(`"`+ids[1]+`"`)) {
+				return &http.Response{
+					StatusCode: http.StatusInternalServerError,
+					Body:       ioutil.NopCloser(strings.NewReader(`{"errors":[{"message":"boom"}]}`)),
+					Header:     make(http.Header),
+					Request:    req,
+				}, nil
+			}
+			return nil, nil
+		},
+	}}, "dummy_token", slab.WithEndpoint(srv.URL))
+
+	b := &Backup{Client: client, Dir: dir, Concurrency: 1}
+	_, err := b.Run(context.Background())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "backup: post "+ids[1]+": ")
+	}
+	m, err := LoadManifest(dir)
+	assert.NoError(t, err)
+	assert.Nil(t, m.CompletedAt)
+	assert.Equal(t, []string{ids[0]}, m.PostIDs(), "the posts fetched before the error are recorded")
+}
+
+func TestBackup_Run_Concurrency(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	for i := 0; i < 12; i++ {
+		srv.AddPost(slabtest.Post{Title: "Post", Content: `[{"insert":"Post\n"}]`})
+	}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	inFlight, max := 0, 0
+	client := slab.NewClient(&http.Client{Transport: &transport{
+		before: func(req *http.Request, body []byte) (*http.Response, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > max {
+				max = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return nil, nil
+		},
+	}}, "dummy_token", slab.WithEndpoint(srv.URL))
+
+	b := &Backup{Client: client, Dir: dir, Concurrency: 3}
+	m, err := b.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, m.Posts, 12)
+	assert.True(t, max > 1 && max <= 3, "expecting at most 3 requests at the same time, got %d", max)
+}
+
+func TestBackup_Run_Archive(t *testing.T) {
+	srv, ids := newServer()
+	defer srv.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	archive := filepath.Join(dir, "backup.tar.gz")
+
+	b := &Backup{Client: srv.Client(), Dir: filepath.Join(dir, "slab"), Archive: archive}
+	_, err := b.Run(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	f, err := os.Open(archive)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if !assert.NoError(t, err) {
+		return
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	var manifest []byte
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		names = append(names, hdr.Name)
+		if hdr.Name == "slab/manifest.json" {
+			manifest, _ = ioutil.ReadAll(tr)
+		}
+	}
+	assert.Equal(t, []string{
+		"slab/",
+		"slab/manifest.json",
+		"slab/organization.json",
+		"slab/posts/",
+		"slab/posts/" + ids[0] + ".json",
+		"slab/posts/" + ids[1] + ".json",
+		"slab/posts/" + ids[2] + ".json",
+		"slab/topics.json",
+		"slab/users.json",
+	}, names)
+	assert.Contains(t, string(manifest), `"completedAt"`)
+}
+
+func TestBackup_Run_ArchiveRetry(t *testing.T) {
+	srv, _ := newServer()
+	defer srv.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := &Backup{Client: srv.Client(), Dir: filepath.Join(dir, "slab"), Archive: filepath.Join(dir, "missing", "backup.tar.gz")}
+	m, err := b.Run(context.Background())
+	assert.Error(t, err)
+	if assert.NotNil(t, m) {
+		assert.NotNil(t, m.CompletedAt, "the backup is complete even though the archive failed")
+	}
+
+	srv.Mu.Lock()
+	calls := srv.Calls["post"]
+	srv.Mu.Unlock()
+	b.Archive = filepath.Join(dir, "backup.tar.gz")
+	m, err = b.Run(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, m.Posts, 3)
+	_, err = os.Stat(b.Archive)
+	assert.NoError(t, err)
+	srv.Mu.Lock()
+	assert.Equal(t, calls, srv.Calls["post"], "the complete backup is only archived")
+	srv.Mu.Unlock()
+}
+
+func TestWriteArchive_Incomplete(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, NewManifest().Save(dir))
+
+	var buf bytes.Buffer
+	err := WriteArchive(&buf, dir)
+	assert.EqualError(t, err, "backup: the backup in "+dir+" is not complete")
+}
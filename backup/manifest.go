@@ -0,0 +1,125 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// manifestVersion is the version of the layout of the backups.
+const manifestVersion = 1
+
+// ManifestFile is the name of the manifest in the backup directory.
+const ManifestFile = "manifest.json"
+
+// Manifest describes a backup. It is saved regularly while the posts are fetched, so that an interrupted backup can
+// be resumed.
+type Manifest struct {
+	// Version is the version of the layout of the backup.
+	Version int `json:"version"`
+	// OrganizationID, OrganizationName and Host identify the organization backed up.
+	OrganizationID   string    `json:"organizationId"`
+	OrganizationName string    `json:"organizationName"`
+	Host             string    `json:"host"`
+	StartedAt        time.Time `json:"startedAt"`
+	// CompletedAt is nil until every post is backed up.
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	// Topics and Users are the numbers of topics and users backed up.
+	Topics int `json:"topics"`
+	Users  int `json:"users"`
+	// Posts maps the ids of the posts backed up to their entry.
+	Posts map[string]*PostEntry `json:"posts"`
+	// Missing are the ids of the posts listed by the organization which could not be found when fetched, i.e.
+	// because they were deleted during the backup.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// PostEntry describes a post of the backup.
+type PostEntry struct {
+	Title   string `json:"title"`
+	Version int    `json:"version"`
+	// File is the path of the JSON file of the post, relative to the backup directory and using forward slashes.
+	File string `json:"file"`
+	// Hash is the hex encoded SHA-256 of the JSON file.
+	Hash       string    `json:"hash"`
+	BackedUpAt time.Time `json:"backedUpAt"`
+}
+
+// NewManifest returns the manifest of a backup starting now.
+func NewManifest() *Manifest {
+	return &Manifest{Version: manifestVersion, StartedAt: time.Now().UTC(), Posts: make(map[string]*PostEntry)}
+}
+
+// LoadManifest reads the manifest of the backup stored in the given directory. A new manifest is returned when the
+// directory holds no backup yet.
+func LoadManifest(dir string) (*Manifest, error) {
+	filename := filepath.Join(dir, ManifestFile)
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return NewManifest(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("backup: invalid manifest %s: %w", filename, err)
+	}
+	if m.Version > manifestVersion {
+		return nil, fmt.Errorf("backup: manifest %s has version %d, only %d is supported", filename, m.Version, manifestVersion)
+	}
+	if m.Posts == nil {
+		m.Posts = make(map[string]*PostEntry)
+	}
+	return m, nil
+}
+
+// Save writes the manifest in the given directory. The file is replaced atomically so that an interrupted save does
+// not lose the previous state.
+func (m *Manifest) Save(dir string) error {
+	m.Version = manifestVersion
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, ManifestFile), append(b, '\n'))
+}
+
+// PostIDs returns the ids of the posts backed up, sorted.
+func (m *Manifest) PostIDs() []string {
+	ids := make([]string, 0, len(m.Posts))
+	for id := range m.Posts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// writeFileAtomic writes data to a temporary file renamed to filename, so that filename is never partially written.
+func writeFileAtomic(filename string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// hashContent returns the hex encoded SHA-256 of content.
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadManifest(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	m, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("Expecting no error for a missing manifest, got: %v", err)
+	}
+	assert.Equal(t, manifestVersion, m.Version)
+	assert.Empty(t, m.Posts)
+	assert.Nil(t, m.CompletedAt)
+
+	at := time.Date(2020, 5, 4, 10, 30, 0, 0, time.UTC)
+	m.StartedAt = at
+	m.OrganizationID, m.OrganizationName, m.Host = "org1", "Test org", "test.slab.com"
+	m.Posts["post1"] = &PostEntry{
+		Title: "Welcome", Version: 2, File: "posts/post1.json", Hash: hashContent([]byte("{}")), BackedUpAt: at,
+	}
+	m.Missing = []string{"post2"}
+	if err := m.Save(dir); err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 1, "the temporary file must be removed")
+
+	got, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("LoadManifest returned %+v, expected %+v", got, m)
+	}
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ManifestFile), []byte(`{"version": 2}`), 0644))
+	_, err = LoadManifest(dir)
+	assert.EqualError(t, err, "backup: manifest "+filepath.Join(dir, ManifestFile)+" has version 2, only 1 is supported")
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ManifestFile), []byte(`{`), 0644))
+	_, err = LoadManifest(dir)
+	assert.Contains(t, err.Error(), "backup: invalid manifest")
+}
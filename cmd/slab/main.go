@@ -0,0 +1,399 @@
+// The slab command gives access to the slab.com API from the command line.
+//
+// Usage:
+//
+//	slab <service> <action> [flags] [arguments]
+//
+// The services and their actions are:
+//
+//	org get                               shows the organization
+//	org backup <dir>                      saves the posts, topics and users to a directory, resuming an interrupted
+//	                                      backup, and to a tar.gz file with -archive
+//	posts list                            lists the posts
+//	posts get <id>                        shows a post, -content prints its content as markdown
+//	posts sync <external-id> [file]       creates or updates a post from a markdown or HTML file, stdin by default,
+//	                                      and adds it to the topic given with -topic
+//	posts delete [id]                     deletes a post, by external id with -external-id
+//	topics list                           lists the topics, as a tree with -tree
+//	topics get <id>                       shows a topic
+//	topics create <name>                  creates a topic
+//	topics attach <topic-id> <post-id>    adds a topic to a post
+//	topics detach <topic-id> <post-id>    removes a topic from a post
+//	topics autogen <path>                 creates the missing topics of a path like "Engineering/Services"
+//	users list                            lists the users
+//	users get <id>                        shows a user
+//
+// Every action accepts the -token flag, the API token which defaults to the SLAB_TOKEN environment variable, the
+// -endpoint flag to use another graphql endpoint and the -timeout flag to limit the duration of the requests, 30s by
+// default.
+//
+// These settings can also be kept in the profiles of the configuration file, ~/.config/slab/config.yaml by default
+// or the one given with the -config flag (see slab.Config for its format). The profile is selected with the -profile
+// flag or the SLAB_PROFILE environment variable, the default profile of the file being used otherwise. The flags
+// take precedence over the profile, and the token of a selected profile over SLAB_TOKEN, which itself takes
+// precedence over the token of the default profile. The default topic of the profile is the one the synced posts are
+// added to when no -topic is given.
+//
+// The actions printing objects accept the -output flag to print them as an aligned table (the default), json, yaml
+// or csv, and the -fields flag to select the fields printed, i.e. -fields id,title. With the -format flag, a Go
+// template is printed for every object instead:
+//
+//	slab topics list -format '{{.ID}} {{.Name}}'
+//	slab posts list -output json | jq -r '.[].title'
+//	slab users list -output csv -fields name,email > users.csv
+//
+// The names of the fields are the ones of the JSON output, the fields of the templates are the ones of the objects of
+// the slab package, i.e. Post for the posts. The actions changing an object print a message unless one of these flags
+// is given.
+//
+// The exit status is 0 on success, 1 when the request fails, 2 when the command line is invalid and 3 when the
+// requested object does not exist.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/VEVO/slab-go/slab"
+)
+
+// The exit statuses of the command.
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitNotFound = 3
+)
+
+// command is an action on a service, i.e. `posts get`.
+type command struct {
+	service, action string
+	// usage describes the arguments of the action.
+	usage   string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+var commands = []command{
+	{"org", "get", "", "show the organization", orgGet},
+	{"org", "backup", "<dir>", "back up the organization to a directory", orgBackup},
+	{"posts", "list", "", "list the posts", postsList},
+	{"posts", "get", "<id>", "show a post", postsGet},
+	{"posts", "sync", "<external-id> [file]", "create or update a post from a file or stdin", postsSync},
+	{"posts", "delete", "[id]", "delete a post, by id or external id", postsDelete},
+	{"topics", "list", "", "list the topics", topicsList},
+	{"topics", "get", "<id>", "show a topic", topicsGet},
+	{"topics", "create", "<name>", "create a topic", topicsCreate},
+	{"topics", "attach", "<topic-id> <post-id>", "add a topic to a post", topicsAttach},
+	{"topics", "detach", "<topic-id> <post-id>", "remove a topic from a post", topicsDetach},
+	{"topics", "autogen", "<path>", "create the missing topics of a path", topicsAutogen},
+	{"users", "list", "", "list the users", usersList},
+	{"users", "get", "<id>", "show a user", usersGet},
+}
+
+// usageError is returned for invalid command lines. The usage of the command is printed along with it.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// notFoundError is returned when the API answers null for the requested object without reporting an error. It
+// matches slab.ErrNotFound so that the exit status is exitNotFound all the same.
+type notFoundError struct {
+	object string
+}
+
+func (e *notFoundError) Error() string {
+	return e.object + " not found"
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == slab.ErrNotFound
+}
+
+// app holds the environment of the running command and the flags common to all the actions.
+type app struct {
+	ctx    context.Context
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+
+	// defaultConfig is the configuration file used when no -config flag is given. It is not an error for it to be
+	// missing.
+	defaultConfig string
+
+	cmd         *command
+	flags       *flag.FlagSet
+	out         *outputFlags
+	token       string
+	endpoint    string
+	timeout     time.Duration
+	profileName string
+	config      string
+
+	// profile is the profile used by the command, nil without configuration.
+	profile *slab.Profile
+	// selected is set when the profile was requested with -profile or SLAB_PROFILE.
+	selected bool
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		cancel()
+	}()
+	a := &app{ctx: ctx, stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv}
+	a.defaultConfig, _ = slab.DefaultConfigPath()
+	code := a.run(os.Args[1:])
+	cancel()
+	os.Exit(code)
+}
+
+// run runs the command line and returns the exit status.
+func (a *app) run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		a.usage(a.stderr, "")
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+	if len(args) < 2 {
+		fmt.Fprintf(a.stderr, "slab: missing action for %s\n\n", args[0])
+		a.usage(a.stderr, args[0])
+		return exitUsage
+	}
+	for i := range commands {
+		if commands[i].service == args[0] && commands[i].action == args[1] {
+			a.cmd = &commands[i]
+			break
+		}
+	}
+	if a.cmd == nil {
+		fmt.Fprintf(a.stderr, "slab: unknown command %q\n\n", args[0]+" "+args[1])
+		a.usage(a.stderr, args[0])
+		return exitUsage
+	}
+
+	err := a.cmd.run(a, args[2:])
+	var uErr *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		a.commandUsage(a.stderr)
+		return exitOK
+	case errors.As(err, &uErr):
+		fmt.Fprintf(a.stderr, "slab: %s\n\n", err)
+		a.commandUsage(a.stderr)
+		return exitUsage
+	case slab.IsNotFound(err):
+		fmt.Fprintf(a.stderr, "slab: %s\n", err)
+		return exitNotFound
+	default:
+		fmt.Fprintf(a.stderr, "slab: %s\n", err)
+		return exitError
+	}
+}
+
+// usage writes the list of the commands, of the given service only if it is known.
+func (a *app) usage(w io.Writer, service string) {
+	known := false
+	for _, c := range commands {
+		known = known || c.service == service
+	}
+	fmt.Fprintln(w, "Usage: slab <service> <action> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		if known && c.service != service {
+			continue
+		}
+		fmt.Fprintf(w, "  %-42s %s\n", strings.TrimSpace(c.service+" "+c.action+" "+c.usage), c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "slab <service> <action> -h" for the flags of a command.`)
+}
+
+// newFlagSet returns the flag set of the running action, with the common flags already defined.
+func (a *app) newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(a.cmd.service+" "+a.cmd.action, flag.ContinueOnError)
+	// The errors and the usage are printed by run
+	fs.SetOutput(ioutil.Discard)
+	fs.Usage = func() {}
+	fs.StringVar(&a.token, "token", "", "API token, defaults to the SLAB_TOKEN environment variable or the profile")
+	fs.StringVar(&a.endpoint, "endpoint", "", "graphql endpoint of the API, defaults to the profile or "+slab.DefaultEndpoint)
+	fs.DurationVar(&a.timeout, "timeout", 0, "maximum duration of each request, defaults to the profile or 30s")
+	fs.StringVar(&a.profileName, "profile", "", "profile of the configuration file to use, defaults to SLAB_PROFILE")
+	fs.StringVar(&a.config, "config", "", "configuration file, defaults to "+a.defaultConfigName())
+	a.flags = fs
+	return fs
+}
+
+// isSet tells whether the flag of the given name was given on the command line.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+// commandUsage writes the usage of the running action and its flags.
+func (a *app) commandUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: slab %s %s [flags] %s\n\n%s.\n",
+		a.cmd.service, a.cmd.action, a.cmd.usage, strings.ToUpper(a.cmd.summary[:1])+a.cmd.summary[1:])
+	if a.flags == nil {
+		return
+	}
+	fmt.Fprintln(w, "\nFlags:")
+	a.flags.SetOutput(w)
+	a.flags.PrintDefaults()
+	a.flags.SetOutput(ioutil.Discard)
+}
+
+// parse parses the flags of the action and checks that the number of remaining arguments is between min and max.
+// Unlike the flag package, flags are also accepted after the arguments.
+func (a *app) parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+			return nil, &usageError{msg: err.Error()}
+		}
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			// Everything after "--" is an argument
+			positional = append(positional, rest...)
+			break
+		}
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	switch {
+	case len(positional) < min:
+		return nil, usagef("missing arguments, expected %s", a.cmd.usage)
+	case len(positional) > max:
+		return nil, usagef("too many arguments, expected %s", strings.TrimSpace(a.cmd.usage+" only"))
+	}
+	if a.out != nil {
+		if err := a.out.check(); err != nil {
+			return nil, err
+		}
+	}
+	return positional, nil
+}
+
+// defaultConfigName returns the name of the default configuration file as shown in the usage.
+func (a *app) defaultConfigName() string {
+	if a.defaultConfig == "" {
+		return "none"
+	}
+	return a.defaultConfig
+}
+
+// loadProfile loads the profile selected with -profile or SLAB_PROFILE, or else the default profile of the
+// configuration file if any.
+func (a *app) loadProfile() error {
+	name := a.profileName
+	if name == "" {
+		name = a.getenv("SLAB_PROFILE")
+	}
+	filename := a.config
+	if filename == "" {
+		filename = a.defaultConfig
+	}
+	if filename == "" {
+		if name != "" {
+			return usagef("no configuration file to read the profile %q from, use -config", name)
+		}
+		return nil
+	}
+
+	cfg, err := slab.LoadConfig(filename)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && a.config == "" && name == "":
+		return nil
+	case err != nil:
+		return err
+	}
+	p, err := cfg.Profile(name)
+	switch {
+	case errors.Is(err, slab.ErrProfileNotFound) && name == "":
+		// A configuration without default profile is fine
+		return nil
+	case err != nil:
+		return usagef("%s", err)
+	}
+	a.profile, a.selected = p, name != ""
+	return nil
+}
+
+// client returns the slab client configured from the common flags and the profile.
+func (a *app) client() (*slab.Client, error) {
+	if err := a.loadProfile(); err != nil {
+		return nil, err
+	}
+	p := a.profile
+	if p == nil {
+		p = &slab.Profile{}
+	}
+
+	var tokens slab.TokenSource
+	switch {
+	case a.token != "":
+		tokens = slab.StaticToken(a.token)
+	case a.selected && p.HasToken():
+		tokens = p.TokenSource()
+	case a.getenv("SLAB_TOKEN") != "":
+		tokens = slab.StaticToken(a.getenv("SLAB_TOKEN"))
+	case p.HasToken():
+		tokens = p.TokenSource()
+	default:
+		return nil, usagef("no API token: set the SLAB_TOKEN environment variable, use -token or configure a profile")
+	}
+	endpoint := a.endpoint
+	if endpoint == "" {
+		endpoint = p.Endpoint
+	}
+	if endpoint == "" {
+		endpoint = slab.DefaultEndpoint
+	}
+	timeout := a.timeout
+	if timeout == 0 {
+		timeout = p.Timeout
+	}
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	return slab.NewClient(&http.Client{Timeout: timeout}, "",
+		slab.WithTokenSource(tokens),
+		slab.WithEndpoint(endpoint),
+		slab.WithUserAgent("slab-cli"),
+	), nil
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VEVO/slab-go/internal/slabtest"
+	"github.com/VEVO/slab-go/slab"
+	"github.com/stretchr/testify/assert"
+)
+
+// runCLI runs the command line against the fake server and returns the exit status and the outputs.
+func runCLI(srv *slabtest.Server, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	a := &app{
+		ctx:    context.Background(),
+		stdin:  strings.NewReader(stdin),
+		stdout: &stdout,
+		stderr: &stderr,
+		getenv: func(key string) string {
+			if key == "SLAB_TOKEN" {
+				return "dummy_token"
+			}
+			return ""
+		},
+	}
+	if srv != nil {
+		args = append(args, "-endpoint", srv.URL)
+	}
+	code := a.run(args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	code, stdout, stderr := runCLI(nil, "")
+	assert.Equal(t, exitUsage, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "topics autogen <path>")
+
+	code, _, stderr = runCLI(nil, "", "help")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stderr, "users get <id>")
+
+	code, _, stderr = runCLI(nil, "", "topics")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "missing action for topics")
+	assert.Contains(t, stderr, "topics list")
+	assert.NotContains(t, stderr, "users list")
+
+	code, _, stderr = runCLI(nil, "", "posts", "publish")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown command "posts publish"`)
+
+	code, _, stderr = runCLI(nil, "", "posts", "get", "-h")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stderr, "Usage: slab posts get [flags] <id>")
+	assert.Contains(t, stderr, "-content")
+
+	code, _, stderr = runCLI(nil, "", "posts", "get", "-unknown")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "slab: flag provided but not defined: -unknown")
+
+	code, _, stderr = runCLI(nil, "", "posts", "get")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "slab: missing arguments, expected <id>")
+
+	code, _, stderr = runCLI(nil, "", "users", "get", "a", "b")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "slab: too many arguments, expected <id> only")
+}
+
+func TestRun_MissingToken(t *testing.T) {
+	a := &app{ctx: context.Background(), stdout: ioutil.Discard, stderr: &bytes.Buffer{}, getenv: func(string) string { return "" }}
+	assert.Equal(t, exitUsage, a.run([]string{"org", "get"}))
+	assert.Contains(t, a.stderr.(*bytes.Buffer).String(), "no API token")
+}
+
+func TestRun_Org(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	srv.AddPost(slabtest.Post{Title: "Welcome"})
+	srv.AddTopic("Engineering", "")
+
+	code, stdout, stderr := runCLI(srv, "", "org", "get")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "id:         org1\nname:       Test org\nhost:       test.slab.com\nposts:      1\ntopics:     1\n"+
+		"users:      0\ninsertedAt:\nupdatedAt:\n", stdout)
+}
+
+func TestRun_OrgBackup(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	id := srv.AddPost(slabtest.Post{Title: "Welcome", Content: `[{"insert":"Welcome"},{"insert":"\n","attributes":{"header":1}}]`})
+	srv.AddTopic("Engineering", "")
+	dir, err := ioutil.TempDir("", "slab-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	backupDir, archive := filepath.Join(dir, "backup"), filepath.Join(dir, "backup.tar.gz")
+
+	code, stdout, stderr := runCLI(srv, "", "org", "backup", backupDir, "-markdown", "-archive", archive)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "Backed up 1 posts, 1 topics and 0 users to "+backupDir+"\n", stdout)
+	md, err := ioutil.ReadFile(filepath.Join(backupDir, "posts", id+".md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "# Welcome\n", string(md))
+	_, err = os.Stat(archive)
+	assert.NoError(t, err)
+
+	code, _, stderr = runCLI(srv, "", "org", "backup", backupDir)
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "already holds a complete backup")
+
+	code, _, stderr = runCLI(srv, "", "org", "backup", backupDir, "-concurrency", "0")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "-concurrency must be at least 1")
+}
+
+func TestRun_Posts(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	id := srv.AddPost(slabtest.Post{Title: "Welcome", Content: `[{"insert":"Welcome"},{"insert":"\n","attributes":{"header":1}}]`})
+
+	code, stdout, stderr := runCLI(srv, "", "posts", "list")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "ID     TITLE    PUBLISHED AT  UPDATED AT\n"+id+"  Welcome\n", stdout)
+
+	code, stdout, stderr = runCLI(srv, "", "posts", "get", id)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "title:       Welcome\n")
+
+	code, stdout, stderr = runCLI(srv, "", "posts", "get", id, "-content")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "# Welcome\n", stdout)
+
+	code, _, stderr = runCLI(srv, "", "posts", "get", "nope")
+	assert.Equal(t, exitNotFound, code)
+	assert.Contains(t, stderr, "slab: ")
+	assert.Contains(t, stderr, "nope not found")
+}
+
+func TestRun_PostsSync(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	code, stdout, stderr := runCLI(srv, "# From stdin\n", "posts", "sync", "ext1", "-edit-url", "https://example.com/edit")
+	assert.Equal(t, exitOK, code, stderr)
+	p := srv.PostByExternalID("ext1")
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "Synced post "+p.ID+" (From stdin)\n", stdout)
+		assert.Equal(t, "MARKDOWN", p.Format)
+		assert.Equal(t, "https://example.com/edit", p.EditURL)
+	}
+
+	dir, err := ioutil.TempDir("", "slab-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "page.html")
+	if err := ioutil.WriteFile(file, []byte("<h1>Page</h1>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, _, stderr = runCLI(srv, "", "posts", "sync", "ext2", file, "-edit-url", "https://example.com/edit")
+	assert.Equal(t, exitOK, code, stderr)
+	if p := srv.PostByExternalID("ext2"); assert.NotNil(t, p) {
+		assert.Equal(t, "HTML", p.Format)
+	}
+
+	code, _, stderr = runCLI(srv, "", "posts", "sync", "ext3", file, "-content-format", "docx")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown content format "docx"`)
+
+	code, _, stderr = runCLI(srv, "", "posts", "sync", "ext3", filepath.Join(dir, "missing.md"))
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "missing.md")
+}
+
+func TestRun_PostsDelete(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	id := srv.AddPost(slabtest.Post{Title: "One"})
+	srv.AddPost(slabtest.Post{Title: "Two", ExternalID: "ext2"})
+
+	code, stdout, stderr := runCLI(srv, "", "posts", "delete", id)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "Deleted post "+id+"\n", stdout)
+
+	code, _, stderr = runCLI(srv, "", "posts", "delete", "-external-id", "ext2")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Nil(t, srv.PostByExternalID("ext2"))
+
+	code, _, stderr = runCLI(srv, "", "posts", "delete")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "missing the id of the post or -external-id")
+
+	code, _, _ = runCLI(srv, "", "posts", "delete", "x", "-external-id", "y")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestRun_Topics(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	eng := srv.AddTopic("Engineering", "")
+	svc := srv.AddTopic("Services", eng)
+	post := srv.AddPost(slabtest.Post{Title: "Runbook"})
+
+	code, stdout, stderr := runCLI(srv, "", "topics", "list", "-tree")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "Engineering ("+eng+")\n  Services ("+svc+")\n", stdout)
+
+	code, stdout, stderr = runCLI(srv, "", "topics", "list")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, svc+"  Engineering/Services")
+
+	code, stdout, stderr = runCLI(srv, "", "topics", "get", svc)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "parent:      "+eng+"\n")
+
+	code, stdout, stderr = runCLI(srv, "", "topics", "create", "Runbooks", "-parent", svc, "-description", "How to")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.True(t, strings.HasPrefix(stdout, "Created topic "), stdout)
+
+	code, stdout, stderr = runCLI(srv, "", "topics", "attach", svc, post)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "Attached topic "+svc+" (Services) to post "+post+"\n", stdout)
+	srv.Mu.Lock()
+	assert.Equal(t, []string{svc}, srv.Posts[post].Topics)
+	srv.Mu.Unlock()
+
+	code, stdout, stderr = runCLI(srv, "", "topics", "detach", svc, post)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "Detached topic "+svc+" (Services) from post "+post+"\n", stdout)
+
+	code, _, _ = runCLI(srv, "", "topics", "attach", "nope", post)
+	assert.Equal(t, exitNotFound, code)
+}
+
+func TestRun_TopicsAutogen(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	srv.AddTopic("Engineering", "")
+
+	code, stdout, stderr := runCLI(srv, "", "topics", "autogen", "engineering/CI\\/CD", "-dry-run")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "Would create topic CI/CD\n", stdout)
+	srv.Mu.Lock()
+	assert.Equal(t, 1, len(srv.Topics))
+	srv.Mu.Unlock()
+
+	code, stdout, stderr = runCLI(srv, "", "topics", "autogen", "engineering/CI\\/CD")
+	assert.Equal(t, exitOK, code, stderr)
+	srv.Mu.Lock()
+	if topic := srv.Topics[strings.TrimSpace(stdout)]; assert.NotNil(t, topic) {
+		assert.Equal(t, "CI/CD", topic.Name)
+		assert.Equal(t, "Engineering", srv.Topics[topic.ParentID].Name)
+	}
+	srv.Mu.Unlock()
+
+	code, stdout, _ = runCLI(srv, "", "topics", "autogen", "Engineering > CI/CD", "-separator", ">", "-dry-run")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "All the topics already exist.\n", stdout)
+
+	code, _, stderr = runCLI(srv, "", "topics", "autogen", "Engineering//Services")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "topic name 2 is empty")
+}
+
+func TestRun_Users(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	srv.Users = []slab.User{{ID: "u1", Name: "Ada", Email: "ada@example.com", Type: "ADMIN"}}
+
+	code, stdout, stderr := runCLI(srv, "", "users", "list")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "ID  NAME  EMAIL            TYPE\nu1  Ada   ada@example.com  ADMIN\n", stdout)
+
+	code, stdout, stderr = runCLI(srv, "", "users", "get", "u1")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "email:         ada@example.com\n")
+
+	code, _, _ = runCLI(srv, "", "users", "get", "u2")
+	assert.Equal(t, exitNotFound, code)
+}
+
+func TestRun_NullObject(t *testing.T) {
+	// Without reporting an error, the API answers null for every object requested or mutated
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"data": {"organization": null, "post": null, "topic": null, "user": null, `+
+			`"syncPost": null, "deletePost": null, "createTopic": null, "addTopicToPost": null, "removeTopicFromPost": null}}`)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		args   []string
+		stderr string
+	}{
+		{[]string{"org", "get"}, "slab: organization not found\n"},
+		{[]string{"posts", "get", "p1"}, "slab: post p1 not found\n"},
+		{[]string{"posts", "get", "p1", "-content"}, "slab: post p1 not found\n"},
+		{[]string{"topics", "get", "t1"}, "slab: topic t1 not found\n"},
+		{[]string{"users", "get", "u1"}, "slab: user u1 not found\n"},
+		{[]string{"posts", "sync", "repo:a.md", "-edit-url", "https://example.com/a.md"}, "slab: synced post repo:a.md not found\n"},
+		{[]string{"posts", "delete", "p1"}, "slab: post p1 not found\n"},
+		{[]string{"posts", "delete", "-external-id", "repo:a.md"}, "slab: post with external id repo:a.md not found\n"},
+		{[]string{"topics", "create", "Docs"}, "slab: created topic Docs not found\n"},
+		{[]string{"topics", "attach", "t1", "p1"}, "slab: topic t1 not found\n"},
+		{[]string{"topics", "detach", "t1", "p1"}, "slab: topic t1 not found\n"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			code, stdout, stderr := runCLI(nil, "", append(tt.args, "-endpoint", srv.URL)...)
+			assert.Equal(t, exitNotFound, code)
+			assert.Empty(t, stdout)
+			assert.Equal(t, tt.stderr, stderr)
+		})
+	}
+}
+
+func TestRun_Profiles(t *testing.T) {
+	var tokens []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		_, _ = io.WriteString(w, `{"data": {"organization": {"id": "org1", "name": "Test org"}}}`)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "slab-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "config.yaml")
+	err = ioutil.WriteFile(config, []byte(`
+defaultProfile: work
+profiles:
+  work:
+    token: work_token
+    endpoint: `+srv.URL+`
+  community:
+    token: community_token
+    endpoint: `+srv.URL+`
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		expected string
+	}{
+		{"default profile", nil, nil, "work_token"},
+		{"SLAB_TOKEN over the default profile", map[string]string{"SLAB_TOKEN": "env_token"}, nil, "env_token"},
+		{"selected profile", map[string]string{"SLAB_TOKEN": "env_token"}, []string{"-profile", "community"}, "community_token"},
+		{"SLAB_PROFILE", map[string]string{"SLAB_TOKEN": "env_token", "SLAB_PROFILE": "community"}, nil, "community_token"},
+		{"flag over SLAB_PROFILE", map[string]string{"SLAB_PROFILE": "nope"}, []string{"-profile", "community"}, "community_token"},
+		{"token flag", nil, []string{"-profile", "community", "-token", "flag_token"}, "flag_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens = nil
+			var stderr bytes.Buffer
+			a := &app{
+				ctx:           context.Background(),
+				stdout:        ioutil.Discard,
+				stderr:        &stderr,
+				getenv:        func(key string) string { return tt.env[key] },
+				defaultConfig: config,
+			}
+			code := a.run(append([]string{"org", "get"}, tt.args...))
+			assert.Equal(t, exitOK, code, stderr.String())
+			assert.Equal(t, []string{tt.expected}, tokens)
+		})
+	}
+
+	a := &app{ctx: context.Background(), stdout: ioutil.Discard, stderr: &bytes.Buffer{}, getenv: func(string) string { return "" }, defaultConfig: config}
+	assert.Equal(t, exitUsage, a.run([]string{"org", "get", "-profile", "personal"}))
+	assert.Contains(t, a.stderr.(*bytes.Buffer).String(), `slab: profile not found: "personal", the profiles are: community, work`)
+
+	// An explicit configuration file must exist, the default one may not
+	a = &app{ctx: context.Background(), stdout: ioutil.Discard, stderr: &bytes.Buffer{}, getenv: func(string) string { return "" }}
+	assert.Equal(t, exitError, a.run([]string{"org", "get", "-config", filepath.Join(dir, "missing.yaml")}))
+	a = &app{ctx: context.Background(), stdout: ioutil.Discard, stderr: &bytes.Buffer{}, defaultConfig: filepath.Join(dir, "missing.yaml"),
+		getenv: func(key string) string { return map[string]string{"SLAB_TOKEN": "env_token"}[key] }}
+	tokens = nil
+	assert.Equal(t, exitOK, a.run([]string{"org", "get", "-endpoint", srv.URL}))
+	assert.Equal(t, []string{"env_token"}, tokens)
+}
+
+func TestRun_PostsSyncTopic(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "slab-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "config.yaml")
+	err = ioutil.WriteFile(config, []byte("profiles:\n  default:\n    token: abc\n    defaultTopic: Engineering/Docs\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _, stderr := runCLI(srv, "# One", "posts", "sync", "ext1", "-edit-url", "https://example.com", "-config", config)
+	assert.Equal(t, exitOK, code, stderr)
+	code, _, stderr = runCLI(srv, "# Two", "posts", "sync", "ext2", "-edit-url", "https://example.com", "-config", config,
+		"-topic", "Company")
+	assert.Equal(t, exitOK, code, stderr)
+	code, _, stderr = runCLI(srv, "# Three", "posts", "sync", "ext3", "-edit-url", "https://example.com", "-config", config,
+		"-topic", "")
+	assert.Equal(t, exitOK, code, stderr)
+
+	topicPaths := func(externalID string) []string {
+		p := srv.PostByExternalID(externalID)
+		if p == nil {
+			return nil
+		}
+		var paths []string
+		for _, id := range p.Topics {
+			paths = append(paths, srv.TopicPath(id))
+		}
+		return paths
+	}
+	assert.Equal(t, []string{"Engineering/Docs"}, topicPaths("ext1"))
+	assert.Equal(t, []string{"Company"}, topicPaths("ext2"))
+	assert.Nil(t, topicPaths("ext3"))
+
+	code, _, stderr = runCLI(srv, "# Four", "posts", "sync", "ext4", "-topic", "Engineering//Docs")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "topic name 2 is empty")
+	assert.Nil(t, srv.PostByExternalID("ext4"))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/VEVO/slab-go/backup"
+)
+
+func orgGet(a *app, args []string) error {
+	fs := a.newFlagSet()
+	a.addOutputFlags(fs)
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	o, err := c.Organization.GetContext(a.ctx)
+	if err != nil {
+		return err
+	}
+	if o == nil {
+		return &notFoundError{object: "organization"}
+	}
+	res := &result{single: true}
+	res.add(o, organizationRecord(o))
+	return a.print(res)
+}
+
+func orgBackup(a *app, args []string) error {
+	fs := a.newFlagSet()
+	concurrency := fs.Int("concurrency", backup.DefaultConcurrency, "maximum number of posts fetched at the same time")
+	markdown := fs.Bool("markdown", false, "also save the content of the posts as markdown")
+	archive := fs.String("archive", "", "write a tar.gz `file` of the backup once complete, or of the complete backup already in the directory")
+	verbose := fs.Bool("verbose", false, "report the progress on stderr")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *concurrency < 1 {
+		return usagef("-concurrency must be at least 1")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	b := &backup.Backup{Client: c, Dir: args[0], Concurrency: *concurrency, Markdown: *markdown, Archive: *archive}
+	if *verbose {
+		b.Logf = func(format string, args ...interface{}) {
+			fmt.Fprintf(a.stderr, format+"\n", args...)
+		}
+	}
+	m, err := b.Run(a.ctx)
+	if err != nil {
+		if m != nil {
+			return fmt.Errorf("%w (run the command again to resume the backup)", err)
+		}
+		return err
+	}
+	if len(m.Missing) > 0 {
+		fmt.Fprintf(a.stderr, "slab: %d post(s) were deleted during the backup: %s\n", len(m.Missing), strings.Join(m.Missing, ", "))
+	}
+	_, err = fmt.Fprintf(a.stdout, "Backed up %d posts, %d topics and %d users to %s\n", len(m.Posts), m.Topics, m.Users, args[0])
+	return err
+}
@@ -0,0 +1,502 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/VEVO/slab-go/slab"
+	"gopkg.in/yaml.v2"
+)
+
+// The output formats of the -output flag.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputCSV   = "csv"
+)
+
+// outputFlags are the flags controlling how the objects returned by the API are printed.
+type outputFlags struct {
+	output string
+	fields string
+	format string
+
+	tmpl     *template.Template
+	selected []string
+}
+
+// addOutputFlags defines the output flags on the flag set of an action printing objects.
+func (a *app) addOutputFlags(fs *flag.FlagSet) {
+	a.out = &outputFlags{}
+	fs.StringVar(&a.out.output, "output", outputTable, "output format: table, json, yaml or csv")
+	fs.StringVar(&a.out.fields, "fields", "", "comma separated list of the fields to print, i.e. id,title")
+	fs.StringVar(&a.out.format, "format", "", "Go template printed for every object, i.e. '{{.ID}}', instead of -output")
+}
+
+// check validates the output flags once they are parsed.
+func (o *outputFlags) check() error {
+	switch o.output {
+	case outputTable, outputJSON, outputYAML, outputCSV:
+	default:
+		return usagef("unknown output %q, expected table, json, yaml or csv", o.output)
+	}
+	if o.format != "" {
+		tmpl, err := template.New("format").Funcs(template.FuncMap{"json": toJSON}).Parse(o.format)
+		if err != nil {
+			return usagef("invalid -format: %s", err)
+		}
+		o.tmpl = tmpl
+	}
+	for _, f := range strings.Split(o.fields, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			o.selected = append(o.selected, f)
+		}
+	}
+	return nil
+}
+
+// explicit tells whether the output flags were given, for the actions which print a message by default.
+func (o *outputFlags) explicit() bool {
+	return o.output != outputTable || o.fields != "" || o.format != ""
+}
+
+// value is a named value of a record.
+type value struct {
+	name  string
+	value interface{}
+}
+
+// record is the flat view of an object the tables, the CSV and the -fields selection are built from. The names of
+// its values are the JSON names of the fields of the object.
+type record []value
+
+// MarshalJSON writes the record as an object, keeping the order of its values.
+func (r record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, v := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(v.name)
+		data, err := json.Marshal(v.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// names returns the names of the values of the record.
+func (r record) names() []string {
+	names := make([]string, len(r))
+	for i, v := range r {
+		names[i] = v.name
+	}
+	return names
+}
+
+// get returns the value of the given name, ignoring the case.
+func (r record) get(name string) (interface{}, bool) {
+	for _, v := range r {
+		if strings.EqualFold(v.name, name) {
+			return v.value, true
+		}
+	}
+	return nil, false
+}
+
+// result is what an action prints.
+type result struct {
+	// items are the objects returned by the API, given to the templates and printed as is in JSON and YAML when no
+	// field is selected.
+	items []interface{}
+	// records are the flat views of the items.
+	records []record
+	// columns are the names of the values shown by the table output when no field is selected, all of them when
+	// empty.
+	columns []string
+	// single is set when the action returns one object rather than a list.
+	single bool
+}
+
+// add appends an object and its record to the result.
+func (r *result) add(item interface{}, rec record) {
+	r.items = append(r.items, item)
+	r.records = append(r.records, rec)
+}
+
+// print writes the result to the standard output following the output flags.
+func (a *app) print(res *result) error {
+	o := a.out
+	if o.tmpl != nil {
+		for _, item := range res.items {
+			if err := o.tmpl.Execute(a.stdout, item); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintln(a.stdout); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The names of the values to print, as spelled by the records
+	var names []string
+	if len(o.selected) > 0 {
+		var known record
+		if len(res.records) > 0 {
+			known = res.records[0]
+		}
+		for _, f := range o.selected {
+			found := false
+			for _, n := range known.names() {
+				if strings.EqualFold(n, f) {
+					names, found = append(names, n), true
+					break
+				}
+			}
+			if !found && len(known) > 0 {
+				return usagef("unknown field %q, expected one of %s", f, strings.Join(known.names(), ","))
+			}
+		}
+	}
+
+	switch o.output {
+	case outputJSON, outputYAML:
+		var v interface{}
+		switch {
+		case names != nil && res.single:
+			v = selectValues(res.records[0], names)
+		case names != nil:
+			list := make([]record, len(res.records))
+			for i, r := range res.records {
+				list[i] = selectValues(r, names)
+			}
+			v = list
+		case res.single:
+			v = res.items[0]
+		default:
+			v = res.items
+			if res.items == nil {
+				v = []interface{}{}
+			}
+		}
+		if o.output == outputJSON {
+			data, err := json.MarshalIndent(v, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(a.stdout, "%s\n", data)
+			return err
+		}
+		return writeYAML(a.stdout, v)
+
+	case outputCSV:
+		if names == nil && len(res.records) > 0 {
+			names = res.records[0].names()
+		}
+		w := csv.NewWriter(a.stdout)
+		if err := w.Write(names); err != nil {
+			return err
+		}
+		for _, r := range res.records {
+			if err := w.Write(cells(r, names)); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+
+	default:
+		if names == nil {
+			names = res.columns
+		}
+		if res.single {
+			if names == nil {
+				names = res.records[0].names()
+			}
+			return printFields(a.stdout, res.records[0], names)
+		}
+		if names == nil && len(res.records) > 0 {
+			names = res.records[0].names()
+		}
+		return printTable(a.stdout, res.records, names)
+	}
+}
+
+// printChanged prints the object returned by an action changing it. The message is printed, followed by a new line,
+// unless output flags are given.
+func (a *app) printChanged(item interface{}, rec record, format string, args ...interface{}) error {
+	if a.out.explicit() {
+		res := &result{single: true}
+		res.add(item, rec)
+		return a.print(res)
+	}
+	_, err := fmt.Fprintf(a.stdout, format+"\n", args...)
+	return err
+}
+
+// selectValues returns the values of the record with the given names, in their order.
+func selectValues(r record, names []string) record {
+	selected := make(record, 0, len(names))
+	for _, n := range names {
+		v, _ := r.get(n)
+		selected = append(selected, value{name: n, value: v})
+	}
+	return selected
+}
+
+// cells returns the values of the record with the given names as text.
+func cells(r record, names []string) []string {
+	row := make([]string, len(names))
+	for i, n := range names {
+		v, _ := r.get(n)
+		row[i] = cell(v)
+	}
+	return row
+}
+
+// cell formats a value of a record for the tables and the CSV.
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case []string:
+		return strings.Join(v, ", ")
+	case *slab.DateTime:
+		return formatTime(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// printTable writes the records as columns aligned under a header made of their names, i.e. PUBLISHED AT for
+// publishedAt.
+func printTable(w io.Writer, records []record, names []string) error {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	header := make([]string, len(names))
+	for i, n := range names {
+		header[i] = headerName(n)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range records {
+		row := cells(r, names)
+		for i := range row {
+			row[i] = cellReplacer.Replace(row[i])
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return writeTrimmed(w, &buf)
+}
+
+// cellReplacer keeps the cells of the tables on a single column and line.
+var cellReplacer = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ")
+
+// headerName returns the name of a column, in upper case with the words separated by spaces.
+func headerName(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+	return sb.String()
+}
+
+// printFields writes one `name: value` line per value of the record, the values being aligned.
+func printFields(w io.Writer, r record, names []string) error {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 4, 1, ' ', 0)
+	for i, v := range cells(r, names) {
+		fmt.Fprintf(tw, "%s:\t%s\n", names[i], v)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return writeTrimmed(w, &buf)
+}
+
+// writeTrimmed writes the lines aligned by a tabwriter without the padding of their last, possibly empty, cell.
+func writeTrimmed(w io.Writer, buf *bytes.Buffer) error {
+	lines := strings.SplitAfter(buf.String(), "\n")
+	for i, line := range lines {
+		if strings.HasSuffix(line, "\n") {
+			lines[i] = strings.TrimRight(line[:len(line)-1], " ") + "\n"
+		}
+	}
+	_, err := io.WriteString(w, strings.Join(lines, ""))
+	return err
+}
+
+// writeYAML writes v as YAML. v is converted to JSON first for the objects to have the same keys, in the same
+// order, as in the JSON output.
+func writeYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	doc, err := decodeOrdered(dec)
+	if err != nil {
+		return err
+	}
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// decodeOrdered decodes the next JSON value, the objects as yaml.MapSlice to keep the order of their keys.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			list := []interface{}{}
+			for dec.More() {
+				v, err := decodeOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			_, err = dec.Token()
+			return list, err
+		}
+		obj := yaml.MapSlice{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, yaml.MapItem{Key: key, Value: v})
+		}
+		_, err = dec.Token()
+		return obj, err
+	case json.Number:
+		if i, err := tok.Int64(); err == nil {
+			return i, nil
+		}
+		return tok.Float64()
+	default:
+		return tok, nil
+	}
+}
+
+// toJSON is the json function of the templates.
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// formatTime formats the optional dates of the API, in UTC to not depend on the machine running the command.
+func formatTime(t *slab.DateTime) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func postRecord(p *slab.Post) record {
+	return record{
+		{"id", p.ID},
+		{"title", p.Title},
+		{"version", p.Version},
+		{"insertedAt", p.InsertedAt},
+		{"publishedAt", p.PublishedAt},
+		{"updatedAt", p.UpdatedAt},
+	}
+}
+
+// topicRecord returns the record of a topic, with its path when the tree of the topics is known.
+func topicRecord(t *slab.Topic, tree *slab.TopicTree) record {
+	parent := ""
+	if t.Parent != nil {
+		parent = t.Parent.ID
+	}
+	r := record{
+		{"id", t.ID},
+		{"name", t.Name},
+		{"description", t.Description},
+		{"parent", parent},
+	}
+	if tree != nil {
+		r = append(r, value{"path", slab.JoinTopicPath(tree.Path(t.ID), "/")})
+	}
+	if t.Children != nil {
+		r = append(r, value{"children", len(*t.Children)})
+	}
+	if t.Posts != nil {
+		r = append(r, value{"posts", len(*t.Posts)})
+	}
+	return append(r, value{"insertedAt", t.InsertedAt}, value{"updatedAt", t.UpdatedAt})
+}
+
+func userRecord(u *slab.User) record {
+	return record{
+		{"id", u.ID},
+		{"name", u.Name},
+		{"email", u.Email},
+		{"title", u.Title},
+		{"type", u.Type},
+		{"description", u.Description},
+		{"insertedAt", u.InsertedAt},
+		{"updatedAt", u.UpdatedAt},
+		{"deactivatedAt", u.DeactivatedAt},
+	}
+}
+
+func organizationRecord(o *slab.Organization) record {
+	r := record{
+		{"id", o.ID},
+		{"name", o.Name},
+		{"host", o.Host},
+	}
+	if o.Posts != nil {
+		r = append(r, value{"posts", len(*o.Posts)})
+	}
+	if o.Topics != nil {
+		r = append(r, value{"topics", len(*o.Topics)})
+	}
+	if o.Users != nil {
+		r = append(r, value{"users", len(*o.Users)})
+	}
+	return append(r, value{"insertedAt", o.InsertedAt}, value{"updatedAt", o.UpdatedAt})
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/VEVO/slab-go/internal/slabtest"
+	"github.com/VEVO/slab-go/slab"
+	"github.com/stretchr/testify/assert"
+)
+
+func newUsersServer() *slabtest.Server {
+	srv := slabtest.NewServer()
+	srv.Users = []slab.User{
+		{ID: "u1", Name: "Ada", Email: "ada@example.com", Type: "ADMIN", Description: "Writes,\n\"docs\""},
+		{ID: "u2", Name: "Bob", Email: "bob@example.com", Type: "MEMBER"},
+	}
+	return srv
+}
+
+func TestOutput_JSON(t *testing.T) {
+	srv := newUsersServer()
+	defer srv.Close()
+
+	code, stdout, stderr := runCLI(srv, "", "users", "list", "-output", "json")
+	assert.Equal(t, exitOK, code, stderr)
+	var users []slab.User
+	if assert.NoError(t, json.Unmarshal([]byte(stdout), &users)) {
+		assert.Equal(t, srv.Users, users)
+	}
+
+	code, stdout, stderr = runCLI(srv, "", "users", "list", "-output", "json", "-fields", "Name,id")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, `[
+  {
+    "name": "Ada",
+    "id": "u1"
+  },
+  {
+    "name": "Bob",
+    "id": "u2"
+  }
+]
+`, stdout)
+
+	code, stdout, stderr = runCLI(srv, "", "users", "get", "u2", "-output", "json", "-fields", "email")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "{\n  \"email\": \"bob@example.com\"\n}\n", stdout)
+}
+
+func TestOutput_EmptyList(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	code, stdout, stderr := runCLI(srv, "", "users", "list", "-output", "json")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "[]\n", stdout)
+
+	code, stdout, stderr = runCLI(srv, "", "users", "list")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "ID  NAME  EMAIL  TYPE\n", stdout)
+}
+
+func TestOutput_YAML(t *testing.T) {
+	srv := newUsersServer()
+	defer srv.Close()
+
+	code, stdout, stderr := runCLI(srv, "", "users", "list", "-output", "yaml", "-fields", "id,name")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "- id: u1\n  name: Ada\n- id: u2\n  name: Bob\n", stdout)
+
+	srv.AddPost(slabtest.Post{Title: "Welcome", Version: 3})
+	code, stdout, stderr = runCLI(srv, "", "posts", "list", "-output", "yaml")
+	assert.Equal(t, exitOK, code, stderr)
+	// The keys are the ones of the JSON output, in the same order. The fake server always returns the content
+	assert.Equal(t, "- id: post1\n  title: Welcome\n  content: \"\"\n  version: 3\n", stdout)
+}
+
+func TestOutput_CSV(t *testing.T) {
+	srv := newUsersServer()
+	defer srv.Close()
+
+	code, stdout, stderr := runCLI(srv, "", "users", "list", "-output", "csv", "-fields", "id,description")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "id,description\nu1,\"Writes,\n\"\"docs\"\"\"\nu2,\n", stdout)
+
+	code, stdout, stderr = runCLI(srv, "", "users", "list", "-output", "csv")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "id,name,email,title,type,description,insertedAt,updatedAt,deactivatedAt\n")
+}
+
+func TestOutput_Table(t *testing.T) {
+	srv := newUsersServer()
+	defer srv.Close()
+
+	code, stdout, stderr := runCLI(srv, "", "users", "list", "-fields", "name,description")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "NAME  DESCRIPTION\nAda   Writes, \"docs\"\nBob\n", stdout)
+
+	code, stdout, stderr = runCLI(srv, "", "users", "get", "u1", "-fields", "name,type")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "name: Ada\ntype: ADMIN\n", stdout)
+}
+
+func TestOutput_Template(t *testing.T) {
+	srv := newUsersServer()
+	defer srv.Close()
+
+	code, stdout, stderr := runCLI(srv, "", "users", "list", "--format", "{{.ID}} <{{.Email}}>")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "u1 <ada@example.com>\nu2 <bob@example.com>\n", stdout)
+
+	code, stdout, stderr = runCLI(srv, "", "users", "get", "u2", "-format", "{{json .Name}}")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "\"Bob\"\n", stdout)
+
+	code, _, stderr = runCLI(srv, "", "users", "list", "-format", "{{.ID")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "invalid -format")
+
+	code, _, stderr = runCLI(srv, "", "users", "list", "-format", "{{.Missing}}")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "Missing")
+}
+
+func TestOutput_Errors(t *testing.T) {
+	srv := newUsersServer()
+	defer srv.Close()
+
+	code, _, stderr := runCLI(srv, "", "users", "list", "-output", "xml")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown output "xml"`)
+
+	code, _, stderr = runCLI(srv, "", "users", "list", "-fields", "id,phone")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown field "phone", expected one of id,name,email`)
+
+	code, _, stderr = runCLI(srv, "", "topics", "list", "-tree", "-output", "json")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "-tree can not be combined")
+}
+
+func TestOutput_Changed(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	code, stdout, stderr := runCLI(srv, "", "topics", "create", "Engineering", "-format", "{{.ID}}")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "topic1\n", stdout)
+
+	code, stdout, stderr = runCLI(srv, "", "topics", "create", "Company", "-output", "json", "-fields", "id,name")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "{\n  \"id\": \"topic2\",\n  \"name\": \"Company\"\n}\n", stdout)
+}
+
+func TestCell(t *testing.T) {
+	date := &slab.DateTime{Time: time.Date(2020, 3, 4, 5, 6, 7, 0, time.FixedZone("", 3600))}
+	var noDate *slab.DateTime
+	for _, tt := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, ""},
+		{"text", "text"},
+		{42, "42"},
+		{true, "true"},
+		{[]string{"a", "b"}, "a, b"},
+		{date, "2020-03-04T04:06:07Z"},
+		{noDate, ""},
+		{1.5, "1.5"},
+	} {
+		assert.Equal(t, tt.expected, cell(tt.value))
+	}
+}
+
+func TestHeaderName(t *testing.T) {
+	assert.Equal(t, "ID", headerName("id"))
+	assert.Equal(t, "PUBLISHED AT", headerName("publishedAt"))
+	assert.Equal(t, "DEACTIVATED AT", headerName("deactivatedAt"))
+}
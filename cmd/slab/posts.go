@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/VEVO/slab-go/slab"
+)
+
+func postsList(a *app, args []string) error {
+	fs := a.newFlagSet()
+	a.addOutputFlags(fs)
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	posts, err := c.Post.ListContext(a.ctx)
+	if err != nil {
+		return err
+	}
+	res := &result{columns: []string{"id", "title", "publishedAt", "updatedAt"}}
+	if posts != nil {
+		for i := range *posts {
+			p := &(*posts)[i]
+			res.add(p, postRecord(p))
+		}
+	}
+	return a.print(res)
+}
+
+func postsGet(a *app, args []string) error {
+	fs := a.newFlagSet()
+	a.addOutputFlags(fs)
+	content := fs.Bool("content", false, "print the content of the post as markdown instead of its details")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	p, err := c.Post.GetContext(a.ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return &notFoundError{object: "post " + args[0]}
+	}
+	if *content {
+		d, err := p.ParseContent()
+		if err != nil {
+			return fmt.Errorf("post %s: %w", p.ID, err)
+		}
+		_, err = fmt.Fprint(a.stdout, d.Markdown())
+		return err
+	}
+	res := &result{single: true}
+	res.add(p, postRecord(p))
+	return a.print(res)
+}
+
+func postsSync(a *app, args []string) error {
+	fs := a.newFlagSet()
+	a.addOutputFlags(fs)
+	contentFormat := fs.String("content-format", "", "format of the content, markdown or html, guessed from the extension of the file by default")
+	editURL := fs.String("edit-url", "", "url to edit the source of the post, required when the post is created")
+	readURL := fs.String("read-url", "", "url to read the source of the post, the edit url by default")
+	topic := fs.String("topic", "", `path of the topic to add the post to, i.e. "Engineering/Docs", created if missing, `+
+		"defaults to the default topic of the profile")
+	args, err := a.parse(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+
+	f := "MARKDOWN"
+	switch strings.ToLower(*contentFormat) {
+	case "":
+		if len(args) > 1 {
+			if ext := strings.ToLower(filepath.Ext(args[1])); ext == ".html" || ext == ".htm" {
+				f = "HTML"
+			}
+		}
+	case "markdown", "md":
+	case "html":
+		f = "HTML"
+	default:
+		return usagef("unknown content format %q, expected markdown or html", *contentFormat)
+	}
+	var content []byte
+	if len(args) < 2 || args[1] == "-" {
+		content, err = ioutil.ReadAll(a.stdin)
+	} else {
+		content, err = ioutil.ReadFile(args[1])
+	}
+	if err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	topicPath := *topic
+	if !isSet(fs, "topic") && a.profile != nil {
+		topicPath = a.profile.DefaultTopic
+	}
+	if topicPath != "" {
+		if _, err := slab.ParseTopicPath(topicPath, "/"); err != nil {
+			return usagef("%s", err)
+		}
+	}
+
+	p, err := c.Post.SyncContext(a.ctx, args[0], string(content), *editURL, *readURL, f)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return &notFoundError{object: "synced post " + args[0]}
+	}
+	if topicPath != "" {
+		topicID, err := c.Topic.AutoGenerateContext(a.ctx, topicPath, "/")
+		if err != nil {
+			return fmt.Errorf("post %s synced but not added to %s: %w", p.ID, topicPath, err)
+		}
+		if _, err := c.Topic.AddToPostContext(a.ctx, topicID, p.ID); err != nil {
+			return fmt.Errorf("post %s synced but not added to %s: %w", p.ID, topicPath, err)
+		}
+	}
+	return a.printChanged(p, postRecord(p), "Synced post %s (%s)", p.ID, p.Title)
+}
+
+func postsDelete(a *app, args []string) error {
+	fs := a.newFlagSet()
+	a.addOutputFlags(fs)
+	externalID := fs.String("external-id", "", "delete the post synced with this external id instead of giving its id")
+	args, err := a.parse(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+	var id string
+	switch {
+	case len(args) == 1 && *externalID != "":
+		return usagef("give either the id of the post or -external-id, not both")
+	case len(args) == 1:
+		id = args[0]
+	case *externalID == "":
+		return usagef("missing the id of the post or -external-id")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	p, err := c.Post.DeleteContext(a.ctx, id, *externalID)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		if id == "" {
+			return &notFoundError{object: "post with external id " + *externalID}
+		}
+		return &notFoundError{object: "post " + id}
+	}
+	return a.printChanged(p, postRecord(p), "Deleted post %s", p.ID)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/VEVO/slab-go/slab"
+)
+
+func topicsList(a *app, args []string) error {
+	fs := a.newFlagSet()
+	a.addOutputFlags(fs)
+	tree := fs.Bool("tree", false, "print the hierarchy of the topics instead of a table")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	t, err := c.Topic.TreeContext(a.ctx)
+	if err != nil {
+		return err
+	}
+	if *tree {
+		if a.out.explicit() {
+			return usagef("-tree can not be combined with the output flags")
+		}
+		return t.Walk(func(topic *slab.Topic, depth int) error {
+			_, err := fmt.Fprintf(a.stdout, "%s%s (%s)\n", strings.Repeat("  ", depth), topic.Name, topic.ID)
+			return err
+		})
+	}
+	res := &result{columns: []string{"id", "path", "description"}}
+	err = t.Walk(func(topic *slab.Topic, depth int) error {
+		res.add(topic, topicRecord(topic, t))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return a.print(res)
+}
+
+func topicsGet(a *app, args []string) error {
+	fs := a.newFlagSet()
+	a.addOutputFlags(fs)
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	t, err := c.Topic.GetContext(a.ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return &notFoundError{object: "topic " + args[0]}
+	}
+	res := &result{single: true}
+	res.add(t, topicRecord(t, nil))
+	return a.print(res)
+}
+
+func topicsCreate(a *app, args []string) error {
+	fs := a.newFlagSet()
+	a.addOutputFlags(fs)
+	description := fs.String("description", "", "description of the topic")
+	parent := fs.String("parent", "", "id of the parent topic, the topic is created at the top level by default")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	t, err := c.Topic.CreateContext(a.ctx, args[0], *description, *parent)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return &notFoundError{object: "created topic " + args[0]}
+	}
+	return a.printChanged(t, topicRecord(t, nil), "Created topic %s (%s)", t.ID, t.Name)
+}
+
+func topicsAttach(a *app, args []string) error {
+	return topicsAttachment(a, args, true)
+}
+
+func topicsDetach(a *app, args []string) error {
+	return topicsAttachment(a, args, false)
+}
+
+// topicsAttachment adds a topic to a post or removes it from the post.
+func topicsAttachment(a *app, args []string, attach bool) error {
+	fs := a.newFlagSet()
+	a.addOutputFlags(fs)
+	args, err := a.parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	if attach {
+		t, err := c.Topic.AddToPostContext(a.ctx, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			return &notFoundError{object: "topic " + args[0]}
+		}
+		return a.printChanged(t, topicRecord(t, nil), "Attached topic %s (%s) to post %s", t.ID, t.Name, args[1])
+	}
+	t, err := c.Topic.RemoveFromPostContext(a.ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return &notFoundError{object: "topic " + args[0]}
+	}
+	return a.printChanged(t, topicRecord(t, nil), "Detached topic %s (%s) from post %s", t.ID, t.Name, args[1])
+}
+
+func topicsAutogen(a *app, args []string) error {
+	fs := a.newFlagSet()
+	separator := fs.String("separator", "/", "separator of the topic names in the path")
+	dryRun := fs.Bool("dry-run", false, "print the topics which would be created without creating them")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if _, err := slab.ParseTopicPath(args[0], *separator); err != nil {
+		return usagef("%s", err)
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		missing, err := c.Topic.PlanAutoGenerateContext(a.ctx, args[0], *separator)
+		if err != nil {
+			return err
+		}
+		if len(missing) == 0 {
+			_, err = fmt.Fprintln(a.stdout, "All the topics already exist.")
+			return err
+		}
+		for _, name := range missing {
+			if _, err := fmt.Fprintf(a.stdout, "Would create topic %s\n", name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	id, err := c.Topic.AutoGenerateContext(a.ctx, args[0], *separator)
+	if err != nil {
+		return err
+	}
+	// Only the id is printed for the scripts to use it
+	_, err = fmt.Fprintln(a.stdout, id)
+	return err
+}
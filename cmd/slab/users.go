@@ -0,0 +1,48 @@
+package main
+
+func usersList(a *app, args []string) error {
+	fs := a.newFlagSet()
+	a.addOutputFlags(fs)
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	users, err := c.User.ListContext(a.ctx)
+	if err != nil {
+		return err
+	}
+	res := &result{columns: []string{"id", "name", "email", "type"}}
+	if users != nil {
+		for i := range *users {
+			u := &(*users)[i]
+			res.add(u, userRecord(u))
+		}
+	}
+	return a.print(res)
+}
+
+func usersGet(a *app, args []string) error {
+	fs := a.newFlagSet()
+	a.addOutputFlags(fs)
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	u, err := c.User.GetContext(a.ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return &notFoundError{object: "user " + args[0]}
+	}
+	res := &result{single: true}
+	res.add(u, userRecord(u))
+	return a.print(res)
+}
@@ -0,0 +1,98 @@
+package delta
+
+// blockKind is the kind of a group of lines rendered together.
+type blockKind int
+
+const (
+	paragraphBlock blockKind = iota
+	headerBlock
+	listBlock
+	codeBlock
+	quoteBlock
+	tableBlock
+	dividerBlock
+)
+
+// block is a group of consecutive lines rendered together by the renderers: a list, a code block, a table, a
+// quote... Paragraphs, headers and dividers are made of a single line.
+type block struct {
+	kind  blockKind
+	lines []Line
+}
+
+func kindOf(l Line) blockKind {
+	a := l.Attributes
+	switch {
+	case a.CodeBlock:
+		return codeBlock
+	case a.Table != "":
+		return tableBlock
+	case a.List != "":
+		return listBlock
+	case a.Header > 0:
+		return headerBlock
+	case a.Blockquote:
+		return quoteBlock
+	case len(l.Ops) == 1 && l.Ops[0].Insert.EmbedType() == "divider":
+		return dividerBlock
+	}
+	return paragraphBlock
+}
+
+// blocks groups the lines of the document into blocks. Empty paragraphs, which only add spacing in slab, are
+// dropped.
+func (d *Delta) blocks() []block {
+	var blocks []block
+	for _, l := range d.Lines() {
+		kind := kindOf(l)
+		if kind == paragraphBlock && len(l.Ops) == 0 {
+			continue
+		}
+		n := len(blocks)
+		if n > 0 && blocks[n-1].kind == kind && (kind == listBlock || kind == tableBlock || kind == quoteBlock ||
+			(kind == codeBlock && blocks[n-1].lines[0].Attributes.CodeLanguage == l.Attributes.CodeLanguage)) {
+			blocks[n-1].lines = append(blocks[n-1].lines, l)
+			continue
+		}
+		blocks = append(blocks, block{kind: kind, lines: []Line{l}})
+	}
+	return blocks
+}
+
+// tableRows groups the lines of a table block by row.
+func tableRows(lines []Line) [][]Line {
+	var rows [][]Line
+	for i, l := range lines {
+		if i == 0 || l.Attributes.Table != lines[i-1].Attributes.Table {
+			rows = append(rows, nil)
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], l)
+	}
+	return rows
+}
+
+// inlineFormat holds the inline attributes that matter when rendering text.
+type inlineFormat struct {
+	bold, italic, underline, strike, code bool
+	link                                  string
+}
+
+func formatOf(a Attributes) inlineFormat {
+	return inlineFormat{bold: a.Bold, italic: a.Italic, underline: a.Underline, strike: a.Strike, code: a.Code, link: a.Link}
+}
+
+// mergeOps merges the consecutive text operations having the same inline format so that the renderers don't
+// produce things like `**a****b**`.
+func mergeOps(ops []Op) []Op {
+	var merged []Op
+	for _, op := range ops {
+		n := len(merged)
+		if n > 0 && !op.Insert.IsEmbed() && !merged[n-1].Insert.IsEmbed() &&
+			formatOf(op.Attributes) == formatOf(merged[n-1].Attributes) {
+			merged[n-1].Insert.Text += op.Insert.Text
+			continue
+		}
+		merged = append(merged, op)
+	}
+	return merged
+}
@@ -0,0 +1,413 @@
+// Package delta provides a typed model of the content of slab posts.
+//
+// Slab stores the content of the posts using the Quill delta format: a list of operations, each inserting either
+// some text or an embed (image, divider...) with optional attributes. Inline attributes (bold, link...) are set on
+// the text they apply to while block attributes (header, list, code-block...) are set on the newline ending the
+// line they apply to.
+//
+//	post, err := client.Post.Get(id)
+//	...
+//	d, err := delta.Parse(*post.Content)
+//	...
+//	for _, line := range d.Lines() {
+//		if line.Attributes.Header > 0 {
+//			fmt.Println(line.Text())
+//		}
+//	}
+package delta
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Delta is the content of a post as a list of operations.
+type Delta struct {
+	Ops []Op `json:"ops"`
+}
+
+// Op is a single insert operation. Slab content only ever contains inserts.
+type Op struct {
+	Insert     Insert     `json:"insert"`
+	Attributes Attributes `json:"attributes,omitempty"`
+}
+
+// Insert is what an Op inserts: either some text or an embed.
+type Insert struct {
+	Text string
+	// Embed is set for non-text inserts. It has a single key giving the type of the embed (i.e. "image" or
+	// "divider") associated to its value.
+	Embed map[string]interface{}
+}
+
+// IsEmbed reports whether the insert is an embed rather than text.
+func (i Insert) IsEmbed() bool {
+	return i.Embed != nil
+}
+
+// EmbedType returns the type of the embed, or an empty string for text inserts.
+func (i Insert) EmbedType() string {
+	for k := range i.Embed {
+		return k
+	}
+	return ""
+}
+
+// EmbedValue returns the value of the embed as a string, which is the url for images and videos. It returns an empty
+// string when the value is not a string.
+func (i Insert) EmbedValue() string {
+	for _, v := range i.Embed {
+		if s, ok := v.(string); ok {
+			return s
+		}
+		if m, ok := v.(map[string]interface{}); ok {
+			if s, ok := m["src"].(string); ok {
+				return s
+			}
+			if s, ok := m["url"].(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// UnmarshalJSON reads either a string or an embed object.
+func (i *Insert) UnmarshalJSON(data []byte) error {
+	*i = Insert{}
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &i.Text)
+	}
+	if err := json.Unmarshal(data, &i.Embed); err != nil {
+		return fmt.Errorf("insert must be a string or an object: %v", err)
+	}
+	if i.Embed == nil {
+		i.Embed = map[string]interface{}{}
+	}
+	return nil
+}
+
+// MarshalJSON writes the text or the embed object.
+func (i Insert) MarshalJSON() ([]byte, error) {
+	if i.Embed != nil {
+		return json.Marshal(i.Embed)
+	}
+	return json.Marshal(i.Text)
+}
+
+// List types used in the list attribute.
+const (
+	ListBullet    = "bullet"
+	ListOrdered   = "ordered"
+	ListChecked   = "checked"
+	ListUnchecked = "unchecked"
+)
+
+// Attributes are the formats applied to an insert.
+type Attributes struct {
+	// Inline formats
+	Bold      bool
+	Italic    bool
+	Underline bool
+	Strike    bool
+	Code      bool
+	Link      string
+
+	// Block formats, set on the newline ending the line
+	Header     int
+	List       string
+	Indent     int
+	Blockquote bool
+	// CodeBlock is set for lines of code blocks. CodeLanguage holds the language of the block if known.
+	CodeBlock    bool
+	CodeLanguage string
+	// Table is the identifier of the table row the line is a cell of.
+	Table string
+	Align string
+
+	// Other holds the attributes not known by this package, so that they are not lost.
+	Other map[string]interface{}
+}
+
+// IsZero reports whether no attribute is set.
+func (a Attributes) IsZero() bool {
+	return !a.Bold && !a.Italic && !a.Underline && !a.Strike && !a.Code && a.Link == "" &&
+		a.Header == 0 && a.List == "" && a.Indent == 0 && !a.Blockquote && !a.CodeBlock && a.CodeLanguage == "" &&
+		a.Table == "" && a.Align == "" && len(a.Other) == 0
+}
+
+// UnmarshalJSON reads the attributes object, keeping the unknown attributes in Other.
+func (a *Attributes) UnmarshalJSON(data []byte) error {
+	*a = Attributes{}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for k, v := range raw {
+		if v == nil {
+			continue
+		}
+		var ok bool
+		switch k {
+		case "bold":
+			a.Bold, ok = v.(bool)
+		case "italic":
+			a.Italic, ok = v.(bool)
+		case "underline":
+			a.Underline, ok = v.(bool)
+		case "strike":
+			a.Strike, ok = v.(bool)
+		case "code":
+			a.Code, ok = v.(bool)
+		case "link":
+			a.Link, ok = v.(string)
+		case "header":
+			a.Header, ok = toInt(v)
+		case "list":
+			a.List, ok = v.(string)
+		case "indent":
+			a.Indent, ok = toInt(v)
+		case "blockquote":
+			a.Blockquote, ok = v.(bool)
+		case "code-block":
+			switch cb := v.(type) {
+			case bool:
+				a.CodeBlock, ok = cb, true
+			case string:
+				a.CodeBlock, ok = true, true
+				if cb != "plain" {
+					a.CodeLanguage = cb
+				}
+			}
+		case "table":
+			a.Table, ok = v.(string)
+		case "align":
+			a.Align, ok = v.(string)
+		default:
+			if a.Other == nil {
+				a.Other = make(map[string]interface{})
+			}
+			a.Other[k], ok = v, true
+		}
+		if !ok {
+			return fmt.Errorf("invalid value %v for attribute %q", v, k)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON writes the attributes that are set.
+func (a Attributes) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(a.Other))
+	for k, v := range a.Other {
+		m[k] = v
+	}
+	setBool := func(k string, v bool) {
+		if v {
+			m[k] = true
+		}
+	}
+	setString := func(k, v string) {
+		if v != "" {
+			m[k] = v
+		}
+	}
+	setInt := func(k string, v int) {
+		if v != 0 {
+			m[k] = v
+		}
+	}
+	setBool("bold", a.Bold)
+	setBool("italic", a.Italic)
+	setBool("underline", a.Underline)
+	setBool("strike", a.Strike)
+	setBool("code", a.Code)
+	setString("link", a.Link)
+	setInt("header", a.Header)
+	setString("list", a.List)
+	setInt("indent", a.Indent)
+	setBool("blockquote", a.Blockquote)
+	if a.CodeLanguage != "" {
+		m["code-block"] = a.CodeLanguage
+	} else {
+		setBool("code-block", a.CodeBlock)
+	}
+	setString("table", a.Table)
+	setString("align", a.Align)
+	return json.Marshal(m)
+}
+
+func toInt(v interface{}) (int, bool) {
+	f, ok := v.(float64)
+	if !ok || f != float64(int(f)) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// MarshalJSON writes the op, omitting the attributes when none are set.
+func (o Op) MarshalJSON() ([]byte, error) {
+	if o.Attributes.IsZero() {
+		return json.Marshal(struct {
+			Insert Insert `json:"insert"`
+		}{o.Insert})
+	}
+	return json.Marshal(struct {
+		Insert     Insert     `json:"insert"`
+		Attributes Attributes `json:"attributes"`
+	}{o.Insert, o.Attributes})
+}
+
+// Parse reads the content of a post as returned in Post.Content. Both a bare list of operations and an object with
+// an `ops` field are accepted. The delta is not validated, use Validate for that.
+func Parse(content string) (*Delta, error) {
+	data := bytes.TrimSpace([]byte(content))
+	if len(data) == 0 {
+		return &Delta{}, nil
+	}
+	d := &Delta{}
+	var err error
+	if data[0] == '[' {
+		err = json.Unmarshal(data, &d.Ops)
+	} else {
+		err = json.Unmarshal(data, d)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("delta: invalid content: %v", err)
+	}
+	return d, nil
+}
+
+// Marshal returns the delta in the format used by Post.Content. It only fails when an embed or an attribute was given
+// a value that can not be encoded in JSON.
+func (d *Delta) Marshal() ([]byte, error) {
+	ops := d.Ops
+	if ops == nil {
+		ops = []Op{}
+	}
+	b, err := json.Marshal(ops)
+	if err != nil {
+		return nil, fmt.Errorf("delta: %v", err)
+	}
+	return b, nil
+}
+
+// String returns the delta in the format used by Post.Content, or a description of the error when Marshal fails.
+func (d *Delta) String() string {
+	b, err := d.Marshal()
+	if err != nil {
+		return "%!(" + err.Error() + ")"
+	}
+	return string(b)
+}
+
+// ValidationError describes why a delta is not valid.
+type ValidationError struct {
+	// Op is the index of the faulty operation, or -1 if the error concerns the whole delta.
+	Op     int
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Op < 0 {
+		return "delta: " + e.Reason
+	}
+	return fmt.Sprintf("delta: op %d: %s", e.Op, e.Reason)
+}
+
+// ErrInvalid is matched by errors.Is for all the *ValidationError.
+var ErrInvalid = errors.New("delta: invalid delta")
+
+// Is matches ErrInvalid.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+var validLists = map[string]bool{ListBullet: true, ListOrdered: true, ListChecked: true, ListUnchecked: true}
+
+// Validate checks that the delta is well formed: every op inserts something, block formats are only set on
+// newlines and have valid values, and the document ends with a newline.
+func (d *Delta) Validate() error {
+	for i, op := range d.Ops {
+		if op.Insert.IsEmbed() {
+			if len(op.Insert.Embed) != 1 {
+				return &ValidationError{Op: i, Reason: fmt.Sprintf("embed must have exactly one type, got %d", len(op.Insert.Embed))}
+			}
+		} else if op.Insert.Text == "" {
+			return &ValidationError{Op: i, Reason: "empty insert"}
+		}
+
+		a := op.Attributes
+		if a.Header < 0 || a.Header > 6 {
+			return &ValidationError{Op: i, Reason: fmt.Sprintf("header level %d out of range 1-6", a.Header)}
+		}
+		if a.List != "" && !validLists[a.List] {
+			return &ValidationError{Op: i, Reason: fmt.Sprintf("unknown list type %q", a.List)}
+		}
+		if a.Indent < 0 {
+			return &ValidationError{Op: i, Reason: fmt.Sprintf("negative indent %d", a.Indent)}
+		}
+		isBlock := a.Header > 0 || a.List != "" || a.Indent > 0 || a.Blockquote || a.CodeBlock || a.Table != ""
+		if isBlock && (op.Insert.IsEmbed() || strings.Trim(op.Insert.Text, "\n") != "") {
+			return &ValidationError{Op: i, Reason: "block formats can only be applied to newlines"}
+		}
+	}
+	if len(d.Ops) > 0 {
+		last := d.Ops[len(d.Ops)-1]
+		if last.Insert.IsEmbed() || !strings.HasSuffix(last.Insert.Text, "\n") {
+			return &ValidationError{Op: -1, Reason: "content must end with a newline"}
+		}
+	}
+	return nil
+}
+
+// Line is a line of the document: the inline operations it is made of and the block attributes of the newline
+// ending it.
+type Line struct {
+	// Ops are the inline operations of the line. Their text never contains newlines.
+	Ops []Op
+	// Attributes are the block attributes of the line.
+	Attributes Attributes
+}
+
+// Text returns the text of the line without any formatting. Embeds are ignored.
+func (l Line) Text() string {
+	var sb strings.Builder
+	for _, op := range l.Ops {
+		sb.WriteString(op.Insert.Text)
+	}
+	return sb.String()
+}
+
+// Lines splits the document into lines, which is the easiest way to walk through its structure.
+// A trailing line without a final newline is returned as well.
+func (d *Delta) Lines() []Line {
+	var lines []Line
+	var cur Line
+	for _, op := range d.Ops {
+		if op.Insert.IsEmbed() {
+			cur.Ops = append(cur.Ops, op)
+			continue
+		}
+		parts := strings.Split(op.Insert.Text, "\n")
+		for i, part := range parts {
+			if part != "" {
+				cur.Ops = append(cur.Ops, Op{Insert: Insert{Text: part}, Attributes: op.Attributes})
+			}
+			if i < len(parts)-1 {
+				// The newline carries the block attributes of the line
+				cur.Attributes = op.Attributes
+				lines = append(lines, cur)
+				cur = Line{}
+			}
+		}
+	}
+	if len(cur.Ops) > 0 {
+		lines = append(lines, cur)
+	}
+	return lines
+}
@@ -0,0 +1,161 @@
+package delta
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readmeContent is the content slab returns for the README of this repository.
+const readmeContent = `[{"insert":"slab-go"},{"attributes":{"header":1},"insert":"\n"},{"insert":"slab-go is a Go client library for accessing the "},{"attributes":{"link":"https://the.slab.com/public/slab-api-vk0o0i33"},"insert":"slab.com API"},{"insert":".\nUsage examples can be found in the "},{"attributes":{"code":true},"insert":"examples"},{"insert":" folder of this repository.\n"}]`
+
+func TestParse(t *testing.T) {
+	want := &Delta{Ops: []Op{
+		{Insert: Insert{Text: "slab-go"}},
+		{Insert: Insert{Text: "\n"}, Attributes: Attributes{Header: 1}},
+		{Insert: Insert{Text: "slab-go is a Go client library for accessing the "}},
+		{Insert: Insert{Text: "slab.com API"}, Attributes: Attributes{Link: "https://the.slab.com/public/slab-api-vk0o0i33"}},
+		{Insert: Insert{Text: ".\nUsage examples can be found in the "}},
+		{Insert: Insert{Text: "examples"}, Attributes: Attributes{Code: true}},
+		{Insert: Insert{Text: " folder of this repository.\n"}},
+	}}
+
+	got, err := Parse(readmeContent)
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse returned: %#v\nwant %#v", got, want)
+	}
+	assert.NoError(t, got.Validate())
+
+	// Wrapped in an ops object
+	got, err = Parse(`{"ops":` + readmeContent + `}`)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	got, err = Parse("  ")
+	assert.NoError(t, err)
+	assert.Empty(t, got.Ops)
+
+	_, err = Parse(`[{"insert": 42}]`)
+	assert.Error(t, err)
+	_, err = Parse(`[{"insert": "foo", "attributes": {"header": "big"}}]`)
+	assert.Error(t, err)
+}
+
+func TestParse_Attributes(t *testing.T) {
+	content := `[
+		{"insert":"code"},{"insert":"\n","attributes":{"code-block":"go"}},
+		{"insert":"plain"},{"insert":"\n","attributes":{"code-block":"plain"}},
+		{"insert":"quill"},{"insert":"\n","attributes":{"code-block":true}},
+		{"insert":"done"},{"insert":"\n","attributes":{"list":"checked","indent":1}},
+		{"insert":{"image":"https://example.com/a.png"},"attributes":{"alt":"A"}},
+		{"insert":"cell"},{"insert":"\n","attributes":{"table":"row-1","align":"center"}},
+		{"insert":"x","attributes":{"bold":true,"italic":true,"underline":true,"strike":true,"color":"#ff0000","bold2":null}},
+		{"insert":"\n","attributes":{"blockquote":true}}
+	]`
+	d, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.NoError(t, d.Validate())
+
+	assert.Equal(t, Attributes{CodeBlock: true, CodeLanguage: "go"}, d.Ops[1].Attributes)
+	assert.Equal(t, Attributes{CodeBlock: true}, d.Ops[3].Attributes)
+	assert.Equal(t, Attributes{CodeBlock: true}, d.Ops[5].Attributes)
+	assert.Equal(t, Attributes{List: ListChecked, Indent: 1}, d.Ops[7].Attributes)
+	assert.True(t, d.Ops[8].Insert.IsEmbed())
+	assert.Equal(t, "image", d.Ops[8].Insert.EmbedType())
+	assert.Equal(t, "https://example.com/a.png", d.Ops[8].Insert.EmbedValue())
+	assert.Equal(t, Attributes{Other: map[string]interface{}{"alt": "A"}}, d.Ops[8].Attributes)
+	assert.Equal(t, Attributes{Table: "row-1", Align: "center"}, d.Ops[10].Attributes)
+	assert.Equal(t, Attributes{Bold: true, Italic: true, Underline: true, Strike: true, Other: map[string]interface{}{"color": "#ff0000"}}, d.Ops[11].Attributes)
+	assert.Equal(t, Attributes{Blockquote: true}, d.Ops[12].Attributes)
+}
+
+func TestDelta_String(t *testing.T) {
+	d, err := Parse(readmeContent)
+	assert.NoError(t, err)
+
+	// Round trip through String keeps the same document
+	again, err := Parse(d.String())
+	assert.NoError(t, err)
+	assert.Equal(t, d, again)
+
+	var want, got interface{}
+	assert.NoError(t, json.Unmarshal([]byte(readmeContent), &want))
+	assert.NoError(t, json.Unmarshal([]byte(d.String()), &got))
+	assert.Equal(t, want, got)
+
+	assert.Equal(t, "[]", (&Delta{}).String())
+	code := &Delta{Ops: []Op{{Insert: Insert{Text: "\n"}, Attributes: Attributes{CodeBlock: true, CodeLanguage: "go"}}}}
+	assert.Equal(t, `[{"insert":"\n","attributes":{"code-block":"go"}}]`, code.String())
+}
+
+func TestDelta_Marshal_Error(t *testing.T) {
+	d := &Delta{Ops: []Op{{Insert: Insert{Embed: map[string]interface{}{"chart": make(chan int)}}}}}
+	_, err := d.Marshal()
+	assert.Error(t, err)
+	assert.NotPanics(t, func() {
+		assert.Equal(t, "%!("+err.Error()+")", d.String())
+	})
+}
+
+func TestDelta_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		op      int
+	}{
+		{"empty insert", `[{"insert":""},{"insert":"\n"}]`, 0},
+		{"bad header", `[{"insert":"a"},{"insert":"\n","attributes":{"header":7}}]`, 1},
+		{"bad list", `[{"insert":"a"},{"insert":"\n","attributes":{"list":"star"}}]`, 1},
+		{"negative indent", `[{"insert":"a"},{"insert":"\n","attributes":{"indent":-1}}]`, 1},
+		{"block on text", `[{"insert":"a","attributes":{"header":1}},{"insert":"\n"}]`, 0},
+		{"embed with two types", `[{"insert":{"image":"a","video":"b"}},{"insert":"\n"}]`, 0},
+		{"no final newline", `[{"insert":"a"}]`, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse(tt.content)
+			if !assert.NoError(t, err) {
+				return
+			}
+			err = d.Validate()
+			var verr *ValidationError
+			if assert.True(t, errors.As(err, &verr), "got %v", err) {
+				assert.Equal(t, tt.op, verr.Op)
+			}
+			assert.True(t, errors.Is(err, ErrInvalid))
+		})
+	}
+}
+
+func TestDelta_Lines(t *testing.T) {
+	d, err := Parse(readmeContent)
+	assert.NoError(t, err)
+
+	lines := d.Lines()
+	if !assert.Len(t, lines, 3) {
+		return
+	}
+	assert.Equal(t, "slab-go", lines[0].Text())
+	assert.Equal(t, 1, lines[0].Attributes.Header)
+	assert.Equal(t, "slab-go is a Go client library for accessing the slab.com API.", lines[1].Text())
+	assert.Len(t, lines[1].Ops, 3)
+	assert.Equal(t, "https://the.slab.com/public/slab-api-vk0o0i33", lines[1].Ops[1].Attributes.Link)
+	assert.Equal(t, "Usage examples can be found in the examples folder of this repository.", lines[2].Text())
+	assert.True(t, lines[2].Ops[1].Attributes.Code)
+
+	d, err = Parse(`[{"insert":"a\n\nb"}]`)
+	assert.NoError(t, err)
+	lines = d.Lines()
+	if assert.Len(t, lines, 3) {
+		assert.Equal(t, "", lines[1].Text())
+		assert.Equal(t, "b", lines[2].Text())
+	}
+}
@@ -0,0 +1,313 @@
+package delta
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// HTMLOptions configures the rendering of HTML.
+type HTMLOptions struct {
+	// RewriteLink, when set, is called with the target of every link and returns the url to use instead. This is
+	// handy to turn links to other slab posts into links to where they are published. Returning an empty string
+	// removes the link, keeping its text.
+	RewriteLink func(url string) string
+	// HeadingAnchors adds an id to the headers, generated from their text like GitHub does, so they can be linked to.
+	HeadingAnchors bool
+	// Sanitize makes the output safe to embed in a page showing untrusted content: only http, https, mailto and
+	// relative urls are kept, and the formats that would require inline styles or iframes (colors, videos...) are
+	// dropped.
+	Sanitize bool
+}
+
+// HTML renders the document as an HTML fragment. A nil opts is the same as the zero HTMLOptions.
+//
+// Text is always escaped, the Sanitize option only controls what is done with urls, styles and embeds.
+func (d *Delta) HTML(opts *HTMLOptions) string {
+	if opts == nil {
+		opts = &HTMLOptions{}
+	}
+	r := &htmlRenderer{opts: opts, anchors: map[string]int{}}
+	for _, b := range d.blocks() {
+		r.block(b)
+	}
+	return r.sb.String()
+}
+
+type htmlRenderer struct {
+	opts    *HTMLOptions
+	sb      strings.Builder
+	anchors map[string]int // number of times each anchor has been used
+}
+
+func (r *htmlRenderer) block(b block) {
+	switch b.kind {
+	case headerBlock:
+		l := b.lines[0]
+		tag := fmt.Sprintf("h%d", l.Attributes.Header)
+		r.sb.WriteString("<" + tag)
+		if r.opts.HeadingAnchors {
+			r.sb.WriteString(` id="` + html.EscapeString(r.anchor(l.Text())) + `"`)
+		}
+		r.sb.WriteString(r.align(l) + ">" + r.inline(l.Ops) + "</" + tag + ">\n")
+	case quoteBlock:
+		quote := make([]string, len(b.lines))
+		for i, l := range b.lines {
+			quote[i] = r.inline(l.Ops)
+		}
+		r.sb.WriteString("<blockquote>" + strings.Join(quote, "<br>\n") + "</blockquote>\n")
+	case dividerBlock:
+		r.sb.WriteString("<hr>\n")
+	case codeBlock:
+		r.code(b.lines)
+	case listBlock:
+		r.list(b.lines)
+	case tableBlock:
+		r.table(b.lines)
+	default:
+		l := b.lines[0]
+		r.sb.WriteString("<p" + r.align(l) + ">" + r.inline(l.Ops) + "</p>\n")
+	}
+}
+
+func (r *htmlRenderer) align(l Line) string {
+	switch a := l.Attributes.Align; a {
+	case "center", "right", "justify":
+		if !r.opts.Sanitize {
+			return ` style="text-align: ` + a + `"`
+		}
+	}
+	return ""
+}
+
+func (r *htmlRenderer) code(lines []Line) {
+	code := make([]string, len(lines))
+	for i, l := range lines {
+		code[i] = html.EscapeString(l.Text())
+	}
+	r.sb.WriteString("<pre><code")
+	if lang := lines[0].Attributes.CodeLanguage; lang != "" {
+		r.sb.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+	}
+	r.sb.WriteString(">" + strings.Join(code, "\n") + "</code></pre>\n")
+}
+
+// list renders a list block, nesting the lists according to the indentation of the items.
+func (r *htmlRenderer) list(lines []Line) {
+	type openList struct {
+		tag      string // "ul" or "ol"
+		kind     string // list attribute of the items, checked and unchecked being the same kind
+		itemOpen bool
+	}
+	var stack []*openList
+	closeTop := func() {
+		top := stack[len(stack)-1]
+		if top.itemOpen {
+			r.sb.WriteString("</li>\n")
+		}
+		r.sb.WriteString("</" + top.tag + ">\n")
+		stack = stack[:len(stack)-1]
+	}
+
+	for _, l := range lines {
+		level := l.Attributes.Indent
+		tag, kind, class := "ul", l.Attributes.List, ""
+		switch kind {
+		case ListOrdered:
+			tag = "ol"
+		case ListChecked, ListUnchecked:
+			kind, class = "check", ` class="checklist"`
+		}
+
+		for len(stack) > level+1 {
+			closeTop()
+		}
+		if len(stack) == level+1 && stack[level].kind != kind {
+			closeTop()
+		}
+		if len(stack) == level+1 && stack[level].itemOpen {
+			r.sb.WriteString("</li>\n")
+			stack[level].itemOpen = false
+		}
+		for len(stack) < level+1 {
+			if len(stack) > 0 {
+				r.sb.WriteString("\n")
+			}
+			r.sb.WriteString("<" + tag + class + ">\n")
+			stack = append(stack, &openList{tag: tag, kind: kind})
+		}
+
+		r.sb.WriteString("<li>")
+		switch l.Attributes.List {
+		case ListChecked:
+			r.sb.WriteString(`<input type="checkbox" checked disabled> `)
+		case ListUnchecked:
+			r.sb.WriteString(`<input type="checkbox" disabled> `)
+		}
+		r.sb.WriteString(r.inline(l.Ops))
+		stack[level].itemOpen = true
+	}
+	for len(stack) > 0 {
+		closeTop()
+	}
+}
+
+func (r *htmlRenderer) table(lines []Line) {
+	rows := tableRows(lines)
+	r.sb.WriteString("<table>\n")
+	for i, row := range rows {
+		cell := "td"
+		if i == 0 {
+			cell = "th"
+			r.sb.WriteString("<thead>\n")
+		} else if i == 1 {
+			r.sb.WriteString("<tbody>\n")
+		}
+		r.sb.WriteString("<tr>")
+		for _, l := range row {
+			r.sb.WriteString("<" + cell + r.align(l) + ">" + r.inline(l.Ops) + "</" + cell + ">")
+		}
+		r.sb.WriteString("</tr>\n")
+		if i == 0 {
+			r.sb.WriteString("</thead>\n")
+		}
+	}
+	if len(rows) > 1 {
+		r.sb.WriteString("</tbody>\n")
+	}
+	r.sb.WriteString("</table>\n")
+}
+
+// inline renders the inline operations of a line.
+func (r *htmlRenderer) inline(ops []Op) string {
+	var sb strings.Builder
+	for _, op := range mergeOps(ops) {
+		if op.Insert.IsEmbed() {
+			sb.WriteString(r.embed(op))
+			continue
+		}
+		a := op.Attributes
+		text := html.EscapeString(op.Insert.Text)
+		if a.Code {
+			text = "<code>" + text + "</code>"
+		}
+		if a.Strike {
+			text = "<s>" + text + "</s>"
+		}
+		if a.Underline {
+			text = "<u>" + text + "</u>"
+		}
+		if a.Italic {
+			text = "<em>" + text + "</em>"
+		}
+		if a.Bold {
+			text = "<strong>" + text + "</strong>"
+		}
+		if style := r.style(a); style != "" {
+			text = `<span style="` + html.EscapeString(style) + `">` + text + "</span>"
+		}
+		if href := r.link(a.Link); href != "" {
+			text = `<a href="` + html.EscapeString(href) + `">` + text + "</a>"
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+// style returns the inline style for the color attributes, unless sanitizing.
+func (r *htmlRenderer) style(a Attributes) string {
+	if r.opts.Sanitize {
+		return ""
+	}
+	var styles []string
+	if c, ok := a.Other["color"].(string); ok && isCSSColor(c) {
+		styles = append(styles, "color: "+c)
+	}
+	if c, ok := a.Other["background"].(string); ok && isCSSColor(c) {
+		styles = append(styles, "background-color: "+c)
+	}
+	return strings.Join(styles, "; ")
+}
+
+// isCSSColor accepts the color names and hexadecimal values, which is what slab uses.
+func isCSSColor(c string) bool {
+	for _, ch := range c {
+		if !unicode.IsLetter(ch) && !unicode.IsDigit(ch) && ch != '#' {
+			return false
+		}
+	}
+	return c != ""
+}
+
+func (r *htmlRenderer) link(target string) string {
+	if target == "" {
+		return ""
+	}
+	if r.opts.RewriteLink != nil {
+		target = r.opts.RewriteLink(target)
+	}
+	if r.opts.Sanitize && !isSafeURL(target) {
+		return ""
+	}
+	return target
+}
+
+func (r *htmlRenderer) embed(op Op) string {
+	src := op.Insert.EmbedValue()
+	if r.opts.Sanitize && !isSafeURL(src) {
+		return ""
+	}
+	switch op.Insert.EmbedType() {
+	case "image":
+		alt, _ := op.Attributes.Other["alt"].(string)
+		return `<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(alt) + `">`
+	case "divider":
+		return "<hr>"
+	case "video":
+		if !r.opts.Sanitize && src != "" {
+			return `<iframe src="` + html.EscapeString(src) + `" frameborder="0" allowfullscreen></iframe>`
+		}
+	}
+	if src != "" {
+		return `<a href="` + html.EscapeString(src) + `">` + html.EscapeString(src) + "</a>"
+	}
+	return ""
+}
+
+// isSafeURL accepts relative urls and the http, https and mailto schemes.
+func isSafeURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// anchor returns a unique id for a header with the given text, generated like GitHub does.
+func (r *htmlRenderer) anchor(text string) string {
+	var sb strings.Builder
+	for _, ch := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(ch), unicode.IsDigit(ch), ch == '-', ch == '_':
+			sb.WriteRune(ch)
+		case ch == ' ':
+			sb.WriteRune('-')
+		}
+	}
+	id := sb.String()
+	if id == "" {
+		id = "section"
+	}
+	n := r.anchors[id]
+	r.anchors[id]++
+	if n > 0 {
+		id = fmt.Sprintf("%s-%d", id, n)
+	}
+	return id
+}
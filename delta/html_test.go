@@ -0,0 +1,114 @@
+package delta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelta_HTML_Readme(t *testing.T) {
+	want := "<h1>slab-go</h1>\n" +
+		`<p>slab-go is a Go client library for accessing the <a href="https://the.slab.com/public/slab-api-vk0o0i33">slab.com API</a>.</p>` + "\n" +
+		"<p>Usage examples can be found in the <code>examples</code> folder of this repository.</p>\n"
+
+	d, err := Parse(readmeContent)
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, want, d.HTML(nil))
+}
+
+func TestDelta_HTML(t *testing.T) {
+	content := `[
+		{"insert":"Intro"},{"insert":"\n","attributes":{"header":1}},
+		{"insert":"a <b> & "},{"insert":"bold","attributes":{"bold":true,"italic":true}},{"insert":" "},
+		{"insert":"red","attributes":{"color":"#ff0000","underline":true,"strike":true}},{"insert":"\n","attributes":{"align":"center"}},
+		{"insert":"one"},{"insert":"\n","attributes":{"list":"bullet"}},
+		{"insert":"one.one"},{"insert":"\n","attributes":{"list":"ordered","indent":1}},
+		{"insert":"one.two"},{"insert":"\n","attributes":{"list":"ordered","indent":1}},
+		{"insert":"two"},{"insert":"\n","attributes":{"list":"bullet"}},
+		{"insert":"done"},{"insert":"\n","attributes":{"list":"checked"}},
+		{"insert":"todo"},{"insert":"\n","attributes":{"list":"unchecked"}},
+		{"insert":"if a < b {"},{"insert":"\n","attributes":{"code-block":"go"}},
+		{"insert":"}"},{"insert":"\n","attributes":{"code-block":"go"}},
+		{"insert":"Name"},{"insert":"\n","attributes":{"table":"row-1"}},
+		{"insert":"Count"},{"insert":"\n","attributes":{"table":"row-1"}},
+		{"insert":"a"},{"insert":"\n","attributes":{"table":"row-2"}},
+		{"insert":"1"},{"insert":"\n","attributes":{"table":"row-2","align":"right"}},
+		{"insert":{"divider":true}},{"insert":"\n"},
+		{"insert":"quoted"},{"insert":"\n","attributes":{"blockquote":true}},
+		{"insert":{"image":"https://example.com/a.png"},"attributes":{"alt":"An \"image\""}},{"insert":"\n"},
+		{"insert":{"video":"https://example.com/v"}},{"insert":"\n"},
+		{"insert":"Intro"},{"insert":"\n","attributes":{"header":2}}
+	]`
+	want := "<h1>Intro</h1>\n" +
+		`<p style="text-align: center">a &lt;b&gt; &amp; <strong><em>bold</em></strong> <span style="color: #ff0000"><u><s>red</s></u></span></p>` + "\n" +
+		"<ul>\n<li>one\n<ol>\n<li>one.one</li>\n<li>one.two</li>\n</ol>\n</li>\n<li>two</li>\n</ul>\n" +
+		"<ul class=\"checklist\">\n<li><input type=\"checkbox\" checked disabled> done</li>\n<li><input type=\"checkbox\" disabled> todo</li>\n</ul>\n" +
+		"<pre><code class=\"language-go\">if a &lt; b {\n}</code></pre>\n" +
+		"<table>\n<thead>\n<tr><th>Name</th><th>Count</th></tr>\n</thead>\n<tbody>\n<tr><td>a</td><td style=\"text-align: right\">1</td></tr>\n</tbody>\n</table>\n" +
+		"<hr>\n" +
+		"<blockquote>quoted</blockquote>\n" +
+		`<p><img src="https://example.com/a.png" alt="An &#34;image&#34;"></p>` + "\n" +
+		`<p><iframe src="https://example.com/v" frameborder="0" allowfullscreen></iframe></p>` + "\n" +
+		"<h2>Intro</h2>\n"
+
+	d, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.NoError(t, d.Validate())
+	assert.Equal(t, want, d.HTML(&HTMLOptions{}))
+
+	// With anchors, duplicated headers get a numbered id
+	got := d.HTML(&HTMLOptions{HeadingAnchors: true})
+	assert.Contains(t, got, `<h1 id="intro">Intro</h1>`)
+	assert.Contains(t, got, `<h2 id="intro-1">Intro</h2>`)
+}
+
+func TestDelta_HTML_Quote(t *testing.T) {
+	d, err := Parse(`[{"insert":"first"},{"insert":"\n","attributes":{"blockquote":true}},
+		{"insert":"second"},{"insert":"\n","attributes":{"blockquote":true}},{"insert":"after\n"}]`)
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, "<blockquote>first<br>\nsecond</blockquote>\n<p>after</p>\n", d.HTML(nil))
+}
+
+func TestDelta_HTML_Sanitize(t *testing.T) {
+	content := `[
+		{"insert":"click","attributes":{"link":"javascript:alert(1)"}},{"insert":" "},
+		{"insert":"red","attributes":{"color":"red"}},{"insert":" "},
+		{"insert":"ok","attributes":{"link":"mailto:me@example.com"}},{"insert":"\n","attributes":{"align":"center"}},
+		{"insert":{"image":"data:image/png;base64,AAAA"}},{"insert":{"video":"https://example.com/v"}},{"insert":"\n"}
+	]`
+	d, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	want := `<p>click red <a href="mailto:me@example.com">ok</a></p>` + "\n" +
+		`<p><a href="https://example.com/v">https://example.com/v</a></p>` + "\n"
+	assert.Equal(t, want, d.HTML(&HTMLOptions{Sanitize: true}))
+
+	unsafe := d.HTML(nil)
+	assert.True(t, strings.Contains(unsafe, `href="javascript:alert(1)"`))
+}
+
+func TestDelta_HTML_RewriteLink(t *testing.T) {
+	content := `[
+		{"insert":"other post","attributes":{"link":"https://myorg.slab.com/posts/abc123"}},{"insert":" "},
+		{"insert":"dropped","attributes":{"link":"https://example.com"}},{"insert":"\n"}
+	]`
+	d, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	opts := &HTMLOptions{RewriteLink: func(url string) string {
+		if strings.HasPrefix(url, "https://myorg.slab.com/posts/") {
+			return "/docs/" + strings.TrimPrefix(url, "https://myorg.slab.com/posts/")
+		}
+		return ""
+	}}
+	assert.Equal(t, "<p><a href=\"/docs/abc123\">other post</a> dropped</p>\n", d.HTML(opts))
+}
@@ -0,0 +1,283 @@
+package delta
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Markdown renders the document as GitHub Flavored Markdown.
+//
+// Headers, nested lists, checklists, quotes, code blocks with their language, tables (the first row being used as
+// the header), links, images and dividers are supported. Formats without a markdown equivalent, like underline or
+// colors, are dropped.
+func (d *Delta) Markdown() string {
+	var parts []string
+	for _, b := range d.blocks() {
+		parts = append(parts, markdownBlock(b))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+func markdownBlock(b block) string {
+	switch b.kind {
+	case headerBlock:
+		l := b.lines[0]
+		return strings.Repeat("#", l.Attributes.Header) + " " + markdownInline(l.Ops)
+	case quoteBlock:
+		return markdownQuote(b.lines)
+	case dividerBlock:
+		return "---"
+	case codeBlock:
+		return markdownCode(b.lines)
+	case listBlock:
+		return markdownList(b.lines)
+	case tableBlock:
+		return markdownTable(b.lines)
+	}
+	return escapeLineStart(markdownInline(b.lines[0].Ops))
+}
+
+// markdownQuote renders the lines of a quote as the paragraphs of a single blockquote.
+func markdownQuote(lines []Line) string {
+	paragraphs := make([]string, len(lines))
+	for i, l := range lines {
+		paragraphs[i] = "> " + markdownInline(l.Ops)
+	}
+	return strings.Join(paragraphs, "\n>\n")
+}
+
+func markdownCode(lines []Line) string {
+	code := make([]string, len(lines))
+	for i, l := range lines {
+		code[i] = l.Text()
+	}
+	body := strings.Join(code, "\n")
+	fence := "```"
+	for strings.Contains(body, fence) {
+		fence += "`"
+	}
+	return fence + lines[0].Attributes.CodeLanguage + "\n" + body + "\n" + fence
+}
+
+func markdownList(lines []Line) string {
+	// counters keeps the number of the current item of the ordered lists, per indentation level
+	counters := map[int]int{}
+	// columns keeps the column at which the items nested in the current item start, per indentation level
+	columns := map[int]int{}
+	items := make([]string, len(lines))
+	for i, l := range lines {
+		indent := l.Attributes.Indent
+		for level := range counters {
+			if level > indent {
+				delete(counters, level)
+			}
+		}
+		for level := range columns {
+			if level >= indent {
+				delete(columns, level)
+			}
+		}
+		var marker string
+		// width is the width of the list marker and its space, the checkboxes being part of the content
+		width := 2
+		switch l.Attributes.List {
+		case ListOrdered:
+			counters[indent]++
+			marker = fmt.Sprintf("%d.", counters[indent])
+			width = len(marker) + 1
+		case ListChecked:
+			delete(counters, indent)
+			marker = "- [x]"
+		case ListUnchecked:
+			delete(counters, indent)
+			marker = "- [ ]"
+		default:
+			delete(counters, indent)
+			marker = "-"
+		}
+		pad := listPadding(columns, indent)
+		// The nested items must start at least where the content of their parent does, after the list marker
+		if width < 4 {
+			width = 4
+		}
+		columns[indent] = pad + width
+		items[i] = strings.Repeat(" ", pad) + marker + " " + markdownInline(l.Ops)
+	}
+	return strings.Join(items, "\n")
+}
+
+// listPadding returns the number of spaces before the marker of an item at the given indentation level, from the
+// columns of its parents.
+func listPadding(columns map[int]int, indent int) int {
+	for level := indent - 1; level >= 0; level-- {
+		if c, ok := columns[level]; ok {
+			return c + 4*(indent-1-level)
+		}
+	}
+	return 4 * indent
+}
+
+func markdownTable(lines []Line) string {
+	rows := tableRows(lines)
+	cols := 0
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	var sb strings.Builder
+	for r, row := range rows {
+		sb.WriteString("|")
+		for c := 0; c < cols; c++ {
+			cell := ""
+			if c < len(row) {
+				cell = strings.Replace(markdownInline(row[c].Ops), "|", `\|`, -1)
+			}
+			if cell == "" {
+				sb.WriteString(" |")
+			} else {
+				sb.WriteString(" " + cell + " |")
+			}
+		}
+		if r == 0 {
+			sb.WriteString("\n|")
+			for c := 0; c < cols; c++ {
+				sep := " --- |"
+				if c < len(row) {
+					switch row[c].Attributes.Align {
+					case "center":
+						sep = " :---: |"
+					case "right":
+						sep = " ---: |"
+					}
+				}
+				sb.WriteString(sep)
+			}
+		}
+		if r < len(rows)-1 {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// markdownInline renders the inline operations of a line.
+func markdownInline(ops []Op) string {
+	var sb strings.Builder
+	for _, op := range mergeOps(ops) {
+		if op.Insert.IsEmbed() {
+			sb.WriteString(markdownEmbed(op))
+			continue
+		}
+		a := op.Attributes
+		text := op.Insert.Text
+		if a.Code {
+			text = markdownCodeSpan(text)
+		} else {
+			text = escapeMarkdown(text)
+		}
+		if a.Link != "" {
+			text = "[" + text + "](" + markdownURL(a.Link) + ")"
+		}
+		// Emphasis markers must be next to non-space characters so the spaces are moved outside of them.
+		trimmed := strings.TrimSpace(text)
+		if trimmed != "" {
+			lead := text[:strings.Index(text, trimmed)]
+			trail := text[len(lead)+len(trimmed):]
+			if a.Strike {
+				trimmed = "~~" + trimmed + "~~"
+			}
+			if a.Italic {
+				trimmed = "_" + trimmed + "_"
+			}
+			if a.Bold {
+				trimmed = "**" + trimmed + "**"
+			}
+			text = lead + trimmed + trail
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+func markdownEmbed(op Op) string {
+	switch op.Insert.EmbedType() {
+	case "image":
+		alt, _ := op.Attributes.Other["alt"].(string)
+		return "![" + escapeMarkdown(alt) + "](" + markdownURL(op.Insert.EmbedValue()) + ")"
+	case "divider":
+		// Blank lines around it, so that the text before is not made a setext header
+		return "\n\n---\n\n"
+	}
+	if url := op.Insert.EmbedValue(); url != "" {
+		return "<" + url + ">"
+	}
+	return ""
+}
+
+func markdownCodeSpan(code string) string {
+	fence := "`"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		return fence + " " + code + " " + fence
+	}
+	return fence + code + fence
+}
+
+func markdownURL(url string) string {
+	if strings.ContainsAny(url, " ()") {
+		return "<" + strings.Replace(strings.Replace(url, "<", "%3C", -1), ">", "%3E", -1) + ">"
+	}
+	return url
+}
+
+// escapeMarkdown escapes the characters of text that would otherwise be interpreted as markdown.
+func escapeMarkdown(text string) string {
+	runes := []rune(text)
+	var sb strings.Builder
+	for i, r := range runes {
+		switch r {
+		case '\\', '`', '*', '[', ']', '<', '~':
+			sb.WriteRune('\\')
+		case '_':
+			// Underscores inside words, like in snake_case, are not emphasis markers
+			inWord := i > 0 && i < len(runes)-1 && isWordRune(runes[i-1]) && isWordRune(runes[i+1])
+			if !inWord {
+				sb.WriteRune('\\')
+			}
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// escapeLineStart escapes the beginning of a paragraph that would otherwise be read as a header, a quote, a list or a
+// divider.
+func escapeLineStart(line string) string {
+	switch {
+	case strings.HasPrefix(line, "#"), strings.HasPrefix(line, ">"),
+		strings.HasPrefix(line, "- "), strings.HasPrefix(line, "+ "),
+		// Dividers like `---` or `- - -`, and empty list items
+		line == "+", strings.HasPrefix(line, "-") && strings.Trim(line, "- ") == "":
+		return `\` + line
+	}
+	// Ordered list items: digits followed by a dot or a parenthesis, then a space or nothing
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i > 0 && i < len(line) && (line[i] == '.' || line[i] == ')') && (i+1 == len(line) || line[i+1] == ' ') {
+		return line[:i] + `\` + line[i:]
+	}
+	return line
+}
@@ -0,0 +1,219 @@
+package delta
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelta_Markdown_Readme(t *testing.T) {
+	// This is the markdown synced in TestPostService_Sync and readmeContent what slab returns for it.
+	want := "# slab-go\n\n" +
+		"slab-go is a Go client library for accessing the [slab.com API](https://the.slab.com/public/slab-api-vk0o0i33).\n\n" +
+		"Usage examples can be found in the `examples` folder of this repository.\n"
+
+	d, err := Parse(readmeContent)
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, want, d.Markdown())
+}
+
+func TestDelta_Markdown(t *testing.T) {
+	content := `[
+		{"insert":"Title"},{"insert":"\n","attributes":{"header":1}},
+		{"insert":"Some "},{"insert":"bold ","attributes":{"bold":true}},{"insert":"and "},
+		{"insert":"italic","attributes":{"italic":true}},{"insert":" and "},{"insert":"gone","attributes":{"strike":true}},
+		{"insert":" text with snake_case, *stars* and a "},{"insert":"link","attributes":{"link":"https://example.com/a (b)","bold":true}},{"insert":".\n"},
+		{"insert":"\n"},
+		{"insert":"Sub section"},{"insert":"\n","attributes":{"header":2}},
+		{"insert":"one"},{"insert":"\n","attributes":{"list":"bullet"}},
+		{"insert":"one.one"},{"insert":"\n","attributes":{"list":"ordered","indent":1}},
+		{"insert":"one.two"},{"insert":"\n","attributes":{"list":"ordered","indent":1}},
+		{"insert":"one.two.one"},{"insert":"\n","attributes":{"list":"bullet","indent":2}},
+		{"insert":"one.three"},{"insert":"\n","attributes":{"list":"ordered","indent":1}},
+		{"insert":"two"},{"insert":"\n","attributes":{"list":"bullet"}},
+		{"insert":"Checklist"},{"insert":"\n","attributes":{"header":3}},
+		{"insert":"done"},{"insert":"\n","attributes":{"list":"checked"}},
+		{"insert":"todo"},{"insert":"\n","attributes":{"list":"unchecked"}},
+		{"insert":"func main() {"},{"insert":"\n","attributes":{"code-block":"go"}},
+		{"insert":"\tfmt.Println(\"hi\")"},{"insert":"\n","attributes":{"code-block":"go"}},
+		{"insert":"}"},{"insert":"\n","attributes":{"code-block":"go"}},
+		{"insert":"Name"},{"insert":"\n","attributes":{"table":"row-1"}},
+		{"insert":"Count"},{"insert":"\n","attributes":{"table":"row-1","align":"right"}},
+		{"insert":"a|b"},{"insert":"\n","attributes":{"table":"row-2"}},
+		{"insert":"1","attributes":{"code":true}},{"insert":"\n","attributes":{"table":"row-2"}},
+		{"insert":{"divider":true}},{"insert":"\n"},
+		{"insert":"A quote"},{"insert":"\n","attributes":{"blockquote":true}},
+		{"insert":{"image":"https://example.com/a.png"},"attributes":{"alt":"An image"}},{"insert":"\n"},
+		{"insert":"# not a title, "},{"insert":"underlined","attributes":{"underline":true}},{"insert":"\n"},
+		{"insert":"1. not a list"},{"insert":"\n"}
+	]`
+	want := "# Title\n\n" +
+		"Some **bold** and _italic_ and ~~gone~~ text with snake_case, \\*stars\\* and a **[link](<https://example.com/a (b)>)**.\n\n" +
+		"## Sub section\n\n" +
+		"- one\n" +
+		"    1. one.one\n" +
+		"    2. one.two\n" +
+		"        - one.two.one\n" +
+		"    3. one.three\n" +
+		"- two\n\n" +
+		"### Checklist\n\n" +
+		"- [x] done\n" +
+		"- [ ] todo\n\n" +
+		"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n" +
+		"| Name | Count |\n| --- | ---: |\n| a\\|b | `1` |\n\n" +
+		"---\n\n" +
+		"> A quote\n\n" +
+		"![An image](https://example.com/a.png)\n\n" +
+		"\\# not a title, underlined\n\n" +
+		"1\\. not a list\n"
+
+	d, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.NoError(t, d.Validate())
+	assert.Equal(t, want, d.Markdown())
+}
+
+func TestDelta_Markdown_RoundTrip(t *testing.T) {
+	// The contents are written by hand in the delta format slab stores the posts in, as slab would for the markdown
+	// they render to (only readmeContent was captured from slab). Rendering a content must give back its markdown.
+	tests := []struct {
+		name     string
+		markdown string
+		content  string
+	}{
+		{
+			name:     "headers and links",
+			markdown: "# Title\n\n## Section\n\nSee [the docs](https://example.com/docs) and `code`.\n",
+			content: `[{"insert":"Title"},{"insert":"\n","attributes":{"header":1}},
+				{"insert":"Section"},{"insert":"\n","attributes":{"header":2}},
+				{"insert":"See "},{"insert":"the docs","attributes":{"link":"https://example.com/docs"}},{"insert":" and "},
+				{"insert":"code","attributes":{"code":true}},{"insert":".\n"}]`,
+		},
+		{
+			name:     "nested lists",
+			markdown: "- one\n    1. one.one\n    2. one.two\n        - one.two.one\n    3. one.three\n- two\n",
+			content: `[{"insert":"one"},{"insert":"\n","attributes":{"list":"bullet"}},
+				{"insert":"one.one"},{"insert":"\n","attributes":{"list":"ordered","indent":1}},
+				{"insert":"one.two"},{"insert":"\n","attributes":{"list":"ordered","indent":1}},
+				{"insert":"one.two.one"},{"insert":"\n","attributes":{"list":"bullet","indent":2}},
+				{"insert":"one.three"},{"insert":"\n","attributes":{"list":"ordered","indent":1}},
+				{"insert":"two"},{"insert":"\n","attributes":{"list":"bullet"}}]`,
+		},
+		{
+			name:     "checklist",
+			markdown: "- [x] done\n- [ ] todo\n    - [ ] nested todo\n",
+			content: `[{"insert":"done"},{"insert":"\n","attributes":{"list":"checked"}},
+				{"insert":"todo"},{"insert":"\n","attributes":{"list":"unchecked"}},
+				{"insert":"nested todo"},{"insert":"\n","attributes":{"list":"unchecked","indent":1}}]`,
+		},
+		{
+			name:     "table",
+			markdown: "| Name | Count | Note |\n| --- | ---: | :---: |\n| a | 1 | **first** |\n| b | 2 | |\n",
+			content: `[{"insert":"Name"},{"insert":"\n","attributes":{"table":"row-1"}},
+				{"insert":"Count"},{"insert":"\n","attributes":{"table":"row-1","align":"right"}},
+				{"insert":"Note"},{"insert":"\n","attributes":{"table":"row-1","align":"center"}},
+				{"insert":"a"},{"insert":"\n","attributes":{"table":"row-2"}},
+				{"insert":"1"},{"insert":"\n","attributes":{"table":"row-2","align":"right"}},
+				{"insert":"first","attributes":{"bold":true}},{"insert":"\n","attributes":{"table":"row-2","align":"center"}},
+				{"insert":"b"},{"insert":"\n","attributes":{"table":"row-3"}},
+				{"insert":"2"},{"insert":"\n","attributes":{"table":"row-3","align":"right"}},
+				{"insert":"\n","attributes":{"table":"row-3","align":"center"}}]`,
+		},
+		{
+			name:     "images",
+			markdown: "![Architecture](https://example.com/arch.png)\n\nInline ![icon](https://example.com/icon.png) image\n",
+			content: `[{"insert":{"image":"https://example.com/arch.png"},"attributes":{"alt":"Architecture"}},{"insert":"\n"},
+				{"insert":"Inline "},{"insert":{"image":"https://example.com/icon.png"},"attributes":{"alt":"icon"}},
+				{"insert":" image\n"}]`,
+		},
+		{
+			name:     "code blocks with language",
+			markdown: "```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n```sh\ngo test ./...\n```\n\n```\nplain\n```\n",
+			content: `[{"insert":"func main() {"},{"insert":"\n","attributes":{"code-block":"go"}},
+				{"insert":"\tfmt.Println(\"hi\")"},{"insert":"\n","attributes":{"code-block":"go"}},
+				{"insert":"}"},{"insert":"\n","attributes":{"code-block":"go"}},
+				{"insert":"go test ./..."},{"insert":"\n","attributes":{"code-block":"sh"}},
+				{"insert":"plain"},{"insert":"\n","attributes":{"code-block":true}}]`,
+		},
+		{
+			name:     "quotes",
+			markdown: "> first\n>\n> _second_\n\nafter\n",
+			content: `[{"insert":"first"},{"insert":"\n","attributes":{"blockquote":true}},
+				{"insert":"second","attributes":{"italic":true}},{"insert":"\n","attributes":{"blockquote":true}},
+				{"insert":"after\n"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse(tt.content)
+			if err != nil {
+				t.Fatalf("Expecting no error, got: %v", err)
+			}
+			assert.NoError(t, d.Validate())
+			assert.Equal(t, tt.markdown, d.Markdown())
+		})
+	}
+}
+
+func TestDelta_Markdown_EdgeCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		markdown string
+	}{
+		{
+			name:     "divider inside a line",
+			content:  `[{"insert":"before"},{"insert":{"divider":true}},{"insert":"after\n"}]`,
+			markdown: "before\n\n---\n\nafter\n",
+		},
+		{
+			name: "nested under wide ordered markers",
+			content: `[` + strings.Repeat(`{"insert":"item"},{"insert":"\n","attributes":{"list":"ordered"}},`, 100) + `
+				{"insert":"nested"},{"insert":"\n","attributes":{"list":"bullet","indent":1}},
+				{"insert":"deeper"},{"insert":"\n","attributes":{"list":"ordered","indent":2}}]`,
+			markdown: listItems(99) + "100. item\n     - nested\n         1. deeper\n",
+		},
+		{
+			name: "paragraphs looking like blocks",
+			content: `[{"insert":"1) not a list\n"},{"insert":"2.\n"},{"insert":"---\n"},{"insert":"- - -\n"},
+				{"insert":"-\n"},{"insert":"+\n"},{"insert":"10)\n"},{"insert":"3)b\n"}]`,
+			markdown: "1\\) not a list\n\n2\\.\n\n\\---\n\n\\- - -\n\n\\-\n\n\\+\n\n10\\)\n\n3)b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse(tt.content)
+			if err != nil {
+				t.Fatalf("Expecting no error, got: %v", err)
+			}
+			assert.Equal(t, tt.markdown, d.Markdown())
+		})
+	}
+}
+
+// listItems returns the markdown of n top level ordered items named "item".
+func listItems(n int) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "%d. item\n", i)
+	}
+	return sb.String()
+}
+
+func TestDelta_Markdown_Empty(t *testing.T) {
+	assert.Equal(t, "", (&Delta{}).Markdown())
+	d, _ := Parse(`[{"insert":"\n\n"}]`)
+	assert.Equal(t, "", d.Markdown())
+}
+
+func TestMarkdownCodeSpan(t *testing.T) {
+	assert.Equal(t, "`a`", markdownCodeSpan("a"))
+	assert.Equal(t, "``a`b``", markdownCodeSpan("a`b"))
+	assert.Equal(t, "`` `a ``", markdownCodeSpan("`a"))
+}
@@ -1,5 +1,6 @@
 // The topics command provides a way to add / remove / list topics
 // The token is expected to be located in and environment variable called `SLAB_TOKEN`
+// For a complete command line tool, see the slab command in the cmd/slab folder.
 //
 // Usage examples:
 //
@@ -0,0 +1,366 @@
+// Package gitsource derives the urls of the files of a local git checkout on the forge hosting it, to be used as the
+// editURL and readURL of the posts synced to slab.
+//
+// The git metadata is read from the .git directory, the git command is not needed.
+//
+//	repo, err := gitsource.Open(".")
+//	...
+//	editURL, readURL, err := repo.URLs("docs/README.md")
+//	...
+//	post, err := client.Post.Sync(externalID, content, editURL, readURL, "MARKDOWN")
+//
+// GitHub, GitLab and Bitbucket are supported out of the box, including their self-hosted instances when the host name
+// contains "github", "gitlab" or "bitbucket". Other forges can be plugged in with WithForge.
+package gitsource
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrNotRepository is returned by Open when the directory is not inside a git checkout.
+var ErrNotRepository = errors.New("gitsource: not a git repository")
+
+// ErrUnknownForge is returned when no forge is known for the host of the remote.
+var ErrUnknownForge = errors.New("gitsource: unknown forge")
+
+// Forge builds the urls of the files of a repository on a forge.
+type Forge interface {
+	// ReadURL returns the url showing the file at path, using forward slashes and escaped, in the given git ref of
+	// the repository whose web page is base, i.e. https://github.com/owner/repo.
+	ReadURL(base, ref, path string) string
+	// EditURL is like ReadURL but for the url editing the file.
+	EditURL(base, ref, path string) string
+}
+
+// ForgeFunc adapts a function returning both urls of a file to the Forge interface.
+type ForgeFunc func(base, ref, path string) (editURL, readURL string)
+
+// ReadURL implements Forge.
+func (f ForgeFunc) ReadURL(base, ref, path string) string {
+	_, u := f(base, ref, path)
+	return u
+}
+
+// EditURL implements Forge.
+func (f ForgeFunc) EditURL(base, ref, path string) string {
+	u, _ := f(base, ref, path)
+	return u
+}
+
+// The supported forges.
+var (
+	GitHub Forge = ForgeFunc(func(base, ref, path string) (string, string) {
+		return base + "/edit/" + ref + "/" + path, base + "/blob/" + ref + "/" + path
+	})
+	GitLab Forge = ForgeFunc(func(base, ref, path string) (string, string) {
+		return base + "/-/edit/" + ref + "/" + path, base + "/-/blob/" + ref + "/" + path
+	})
+	Bitbucket Forge = ForgeFunc(func(base, ref, path string) (string, string) {
+		u := base + "/src/" + ref + "/" + path
+		return u + "?mode=edit", u
+	})
+)
+
+// Repo is a local git checkout.
+type Repo struct {
+	// Root is the absolute path of the working tree.
+	Root string
+	// RemoteURL is the url of the remote, as configured in git.
+	RemoteURL string
+	// Ref is the branch checked out, or the commit when the HEAD is detached.
+	Ref string
+
+	remote string
+	forges map[string]Forge
+}
+
+// Option configures a Repo.
+type Option func(*Repo)
+
+// WithRemote sets the name of the remote to use. By default `origin` is used, or the first remote when there is no
+// `origin`.
+func WithRemote(name string) Option {
+	return func(r *Repo) {
+		r.remote = name
+	}
+}
+
+// WithRef sets the git ref, i.e. a branch, tag or commit, used in the urls instead of the one checked out. This is
+// useful in CI where the HEAD is usually detached.
+func WithRef(ref string) Option {
+	return func(r *Repo) {
+		r.Ref = ref
+	}
+}
+
+// WithForge makes the given host, i.e. `git.example.com`, use the given forge.
+func WithForge(host string, f Forge) Option {
+	return func(r *Repo) {
+		r.forges[strings.ToLower(host)] = f
+	}
+}
+
+// Open reads the git metadata of the checkout containing dir.
+func Open(dir string, opts ...Option) (*Repo, error) {
+	r := &Repo{
+		forges: map[string]Forge{"github.com": GitHub, "gitlab.com": GitLab, "bitbucket.org": Bitbucket},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	root, gitDir, err := findGitDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	r.Root = root
+
+	if r.Ref == "" {
+		if r.Ref, err = readHead(gitDir); err != nil {
+			return nil, err
+		}
+	}
+
+	// The config of a linked worktree is the one of the main repository
+	configDir := gitDir
+	if b, err := ioutil.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		configDir = resolve(gitDir, strings.TrimSpace(string(b)))
+	}
+	remotes, err := readRemotes(filepath.Join(configDir, "config"))
+	if err != nil {
+		return nil, err
+	}
+	r.RemoteURL, err = pickRemote(remotes, r.remote)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// URLs returns the edit and read urls of the file at the given path, either absolute or relative to the current
+// directory.
+func (r *Repo) URLs(path string) (editURL, readURL string, err error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", err
+	}
+	rel, err := filepath.Rel(r.Root, abs)
+	if err != nil {
+		return "", "", err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", "", fmt.Errorf("gitsource: %s is outside of the repository %s", path, r.Root)
+	}
+	f, base, err := r.forge()
+	if err != nil {
+		return "", "", err
+	}
+	p, ref := escapePath(rel), escapePath(r.Ref)
+	return f.EditURL(base, ref, p), f.ReadURL(base, ref, p), nil
+}
+
+// URLFunc returns a function giving the edit and read urls of the files from their path relative to dir, which is
+// what syncer.Syncer expects.
+func (r *Repo) URLFunc(dir string) (func(relPath string) (editURL, readURL string), error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := r.URLs(abs); err != nil {
+		return nil, err
+	}
+	return func(relPath string) (string, string) {
+		// Both paths are valid so only the forge could fail, which was checked above
+		editURL, readURL, _ := r.URLs(filepath.Join(abs, filepath.FromSlash(relPath)))
+		return editURL, readURL
+	}, nil
+}
+
+// forge returns the forge of the remote and the url of the web page of the repository.
+func (r *Repo) forge() (Forge, string, error) {
+	host, base, err := WebURL(r.RemoteURL)
+	if err != nil {
+		return nil, "", err
+	}
+	if f, ok := r.forges[host]; ok {
+		return f, base, nil
+	}
+	switch {
+	case strings.Contains(host, "github"):
+		return GitHub, base, nil
+	case strings.Contains(host, "gitlab"):
+		return GitLab, base, nil
+	case strings.Contains(host, "bitbucket"):
+		return Bitbucket, base, nil
+	}
+	return nil, "", fmt.Errorf("%w: %s", ErrUnknownForge, host)
+}
+
+// scpLike matches the remotes in the `user@host:path` form used by ssh.
+var scpLike = regexp.MustCompile(`^(?:[\w.-]+@)?([\w.-]+):([^/].*)$`)
+
+// WebURL converts a git remote url, i.e. `git@github.com:owner/repo.git`, to the url of the web page of the
+// repository, `https://github.com/owner/repo`. The lower cased host is returned too.
+func WebURL(remote string) (host, webURL string, err error) {
+	var path string
+	if m := scpLike.FindStringSubmatch(remote); m != nil && !strings.Contains(remote, "://") {
+		host, path = m[1], m[2]
+	} else {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return "", "", fmt.Errorf("gitsource: invalid remote url: %w", err)
+		}
+		if u.Host == "" {
+			return "", "", fmt.Errorf("gitsource: remote %q is not hosted", remote)
+		}
+		host, path = u.Host, u.Path
+		// The ports of ssh or git are not the ones of the web interface
+		if u.Scheme != "http" && u.Scheme != "https" {
+			host = u.Hostname()
+		}
+	}
+	host = strings.ToLower(host)
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if path == "" {
+		return "", "", fmt.Errorf("gitsource: remote %q has no repository path", remote)
+	}
+	scheme := "https"
+	if strings.HasPrefix(remote, "http://") {
+		scheme = "http"
+	}
+	return host, scheme + "://" + host + "/" + path, nil
+}
+
+// findGitDir looks for the .git directory or file from dir up to the root of the file system.
+func findGitDir(dir string) (root, gitDir string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for {
+		p := filepath.Join(dir, ".git")
+		info, err := os.Stat(p)
+		if err == nil {
+			if info.IsDir() {
+				return dir, p, nil
+			}
+			// Worktrees and submodules have a .git file pointing to the real git directory
+			b, err := ioutil.ReadFile(p)
+			if err != nil {
+				return "", "", err
+			}
+			line := strings.TrimSpace(string(b))
+			if !strings.HasPrefix(line, "gitdir:") {
+				return "", "", fmt.Errorf("gitsource: invalid .git file %s", p)
+			}
+			return dir, resolve(dir, strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))), nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", ErrNotRepository
+		}
+		dir = parent
+	}
+}
+
+// resolve returns path, relative to dir unless it is absolute.
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// readHead returns the branch checked out, or the commit of a detached HEAD.
+func readHead(gitDir string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", err
+	}
+	head := strings.TrimSpace(string(b))
+	if strings.HasPrefix(head, "ref:") {
+		return strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(head, "ref:")), "refs/heads/"), nil
+	}
+	return head, nil
+}
+
+// remote is a remote declared in the git config.
+type remote struct {
+	name, url string
+}
+
+// remoteSection matches the `[remote "name"]` sections of the git config.
+var remoteSection = regexp.MustCompile(`^\[\s*remote\s+"(.*)"\s*\]$`)
+
+// readRemotes returns the remotes declared in the git config, in order.
+func readRemotes(config string) ([]remote, error) {
+	f, err := os.Open(config)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var remotes []remote
+	var current *remote
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			current = nil
+			if m := remoteSection.FindStringSubmatch(line); m != nil {
+				remotes = append(remotes, remote{name: m[1]})
+				current = &remotes[len(remotes)-1]
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) == 2 && strings.EqualFold(strings.TrimSpace(kv[0]), "url") {
+			current.url = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		}
+	}
+	return remotes, scanner.Err()
+}
+
+// pickRemote returns the url of the remote of the given name, or of origin or the first remote when name is empty.
+func pickRemote(remotes []remote, name string) (string, error) {
+	for _, want := range []string{name, "origin"} {
+		if want == "" {
+			continue
+		}
+		for _, r := range remotes {
+			if r.name == want && r.url != "" {
+				return r.url, nil
+			}
+		}
+		if name != "" {
+			return "", fmt.Errorf("gitsource: no url for the remote %q", name)
+		}
+	}
+	for _, r := range remotes {
+		if r.url != "" {
+			return r.url, nil
+		}
+	}
+	return "", errors.New("gitsource: the repository has no remote")
+}
+
+// escapePath escapes the segments of a path to be used in a url.
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
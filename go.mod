@@ -1,11 +1,13 @@
 module github.com/VEVO/slab-go
 
 require (
+	github.com/BurntSushi/toml v0.4.1
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/machinebox/graphql v0.2.2
 	github.com/matryer/is v1.2.0 // indirect
 	github.com/pkg/errors v0.8.1 // indirect
 	github.com/stretchr/testify v1.3.0
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 go 1.13
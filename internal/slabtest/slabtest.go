@@ -0,0 +1,407 @@
+// Package slabtest provides an in-memory fake of the slab graphql API to test the code built on top of the slab
+// package.
+//
+// It only understands the queries sent by the slab package, recognizing them by the name of the fields they use.
+package slabtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/VEVO/slab-go/slab"
+)
+
+// Post is a post stored by the fake server.
+type Post struct {
+	ID         string
+	ExternalID string
+	Title      string
+	Content    string
+	Format     string
+	EditURL    string
+	ReadURL    string
+	Version    int
+	// Published is set when the post is created by syncPost, like slab does.
+	Published bool
+	Topics    []string
+}
+
+// Topic is a topic stored by the fake server.
+type Topic struct {
+	ID          string
+	Name        string
+	Description string
+	ParentID    string
+}
+
+// Server is a fake slab API. Its fields can be inspected and modified by the tests, holding Mu when the server is
+// in use.
+type Server struct {
+	*httptest.Server
+
+	Mu     sync.Mutex
+	Posts  map[string]*Post
+	Topics map[string]*Topic
+	Users  []slab.User
+	// Calls counts the number of times each field (i.e. "syncPost" or "organization") was queried.
+	Calls map[string]int
+
+	nextID int
+}
+
+// NewServer starts a fake slab API. It must be closed with Close.
+func NewServer() *Server {
+	s := &Server{
+		Posts:  make(map[string]*Post),
+		Topics: make(map[string]*Topic),
+		Calls:  make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Client returns a slab client talking to the fake server.
+func (s *Server) Client(opts ...slab.ClientOption) *slab.Client {
+	return slab.NewClient(s.Server.Client(), "dummy_token", append([]slab.ClientOption{slab.WithEndpoint(s.URL)}, opts...)...)
+}
+
+// AddTopic adds a topic to the server and returns its ID.
+func (s *Server) AddTopic(name, parentID string) string {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	return s.addTopic(name, "", parentID)
+}
+
+// AddPost adds a post to the server and returns its ID.
+func (s *Server) AddPost(p Post) string {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	p.ID = s.newID("post")
+	s.Posts[p.ID] = &p
+	return p.ID
+}
+
+// PostByExternalID returns the post synced with the given externalID, or nil.
+func (s *Server) PostByExternalID(externalID string) *Post {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	return s.postByExternalID(externalID)
+}
+
+// TopicPath returns the names of the topic and its ancestors, joined with "/".
+func (s *Server) TopicPath(id string) string {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	var names []string
+	for t := s.Topics[id]; t != nil; t = s.Topics[t.ParentID] {
+		names = append([]string{t.Name}, names...)
+	}
+	return strings.Join(names, "/")
+}
+
+func (s *Server) newID(kind string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%d", kind, s.nextID)
+}
+
+func (s *Server) addTopic(name, description, parentID string) string {
+	id := s.newID("topic")
+	s.Topics[id] = &Topic{ID: id, Name: name, Description: description, ParentID: parentID}
+	return id
+}
+
+func (s *Server) postByExternalID(externalID string) *Post {
+	for _, p := range s.Posts {
+		if p.ExternalID == externalID && externalID != "" {
+			return p
+		}
+	}
+	return nil
+}
+
+// fieldRe finds the fields called in a query, with their optional alias and id variable.
+var fieldRe = regexp.MustCompile(`(?:(\w+)\s*:\s*)?\b(organization|post|topic|user|syncPost|deletePost|createPost|updatePost|createTopic|addTopicToPost|removeTopicFromPost)\b\s*(?:\(\s*id:\s*\$(\w+))?`)
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	str := func(k string) string {
+		v, _ := req.Variables[k].(string)
+		return v
+	}
+
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	data := make(map[string]interface{})
+	var errs []map[string]interface{}
+	for _, m := range fieldRe.FindAllStringSubmatch(req.Query, -1) {
+		alias, field, idVar := m[1], m[2], m[3]
+		if alias == "" {
+			alias = field
+		}
+		s.Calls[field]++
+		var res interface{}
+		var err error
+		switch field {
+		case "organization":
+			res = s.organization()
+		case "post":
+			res, err = s.findPost(str(idOr(idVar)))
+		case "topic":
+			res, err = s.findTopic(str(idOr(idVar)))
+		case "user":
+			res, err = s.findUser(str(idOr(idVar)))
+		case "syncPost":
+			res = s.syncPost(str("externalId"), str("content"), str("format"), str("editUrl"), str("readUrl"))
+		case "deletePost":
+			res, err = s.deletePost(str("id"), str("externalId"))
+		case "createPost":
+			res = s.createPost(str("topicId"))
+		case "updatePost":
+			res, err = s.updatePost(req.Variables)
+		case "createTopic":
+			res, err = s.createTopic(str("name"), str("description"), str("parentId"))
+		case "addTopicToPost":
+			res, err = s.attach(str("topicId"), str("postId"), true)
+		case "removeTopicFromPost":
+			res, err = s.attach(str("topicId"), str("postId"), false)
+		}
+		if err != nil {
+			errs = append(errs, map[string]interface{}{
+				"message": err.Error(), "path": []string{alias}, "extensions": map[string]string{"code": "NOT_FOUND"},
+			})
+			res = nil
+		}
+		data[alias] = res
+	}
+
+	body := map[string]interface{}{"data": data}
+	if len(errs) > 0 {
+		body["errors"] = errs
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func idOr(idVar string) string {
+	if idVar == "" {
+		return "id"
+	}
+	return idVar
+}
+
+func (s *Server) postJSON(p *Post) map[string]interface{} {
+	topics := make([]map[string]interface{}, 0, len(p.Topics))
+	for _, id := range p.Topics {
+		if t, ok := s.Topics[id]; ok {
+			topics = append(topics, map[string]interface{}{"id": t.ID, "name": t.Name})
+		}
+	}
+	var publishedAt interface{}
+	if p.Published {
+		publishedAt = "2020-01-01T00:00:00Z"
+	}
+	return map[string]interface{}{
+		"id": p.ID, "title": p.Title, "version": p.Version, "content": p.Content, "topics": topics,
+		"publishedAt": publishedAt,
+	}
+}
+
+func (s *Server) topicJSON(t *Topic) map[string]interface{} {
+	var parent interface{}
+	var ancestors []map[string]string
+	for a := s.Topics[t.ParentID]; a != nil; a = s.Topics[a.ParentID] {
+		if parent == nil {
+			parent = map[string]string{"id": a.ID}
+		}
+		ancestors = append(ancestors, map[string]string{"id": a.ID})
+	}
+	children := []map[string]string{}
+	for _, id := range s.sortedTopicIDs() {
+		if s.Topics[id].ParentID == t.ID {
+			children = append(children, map[string]string{"id": id})
+		}
+	}
+	posts := []map[string]string{}
+	for _, id := range s.sortedPostIDs() {
+		for _, tid := range s.Posts[id].Topics {
+			if tid == t.ID {
+				posts = append(posts, map[string]string{"id": id, "title": s.Posts[id].Title})
+			}
+		}
+	}
+	return map[string]interface{}{
+		"id": t.ID, "name": t.Name, "description": t.Description, "parent": parent,
+		"ancestors": ancestors, "children": children, "posts": posts,
+	}
+}
+
+func (s *Server) sortedPostIDs() []string {
+	ids := make([]string, 0, len(s.Posts))
+	for id := range s.Posts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *Server) sortedTopicIDs() []string {
+	ids := make([]string, 0, len(s.Topics))
+	for id := range s.Topics {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *Server) organization() map[string]interface{} {
+	posts := []map[string]interface{}{}
+	for _, id := range s.sortedPostIDs() {
+		posts = append(posts, s.postJSON(s.Posts[id]))
+	}
+	topics := []map[string]interface{}{}
+	for _, id := range s.sortedTopicIDs() {
+		topics = append(topics, s.topicJSON(s.Topics[id]))
+	}
+	users := s.Users
+	if users == nil {
+		users = []slab.User{}
+	}
+	return map[string]interface{}{
+		"id": "org1", "name": "Test org", "host": "test.slab.com", "posts": posts, "topics": topics, "users": users,
+	}
+}
+
+func (s *Server) findPost(id string) (interface{}, error) {
+	p, ok := s.Posts[id]
+	if !ok {
+		return nil, fmt.Errorf("Post %s not found", id)
+	}
+	return s.postJSON(p), nil
+}
+
+func (s *Server) findTopic(id string) (interface{}, error) {
+	t, ok := s.Topics[id]
+	if !ok {
+		return nil, fmt.Errorf("Topic %s not found", id)
+	}
+	return s.topicJSON(t), nil
+}
+
+func (s *Server) findUser(id string) (interface{}, error) {
+	for _, u := range s.Users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("User %s not found", id)
+}
+
+// titleOf mimics slab which uses the first line of the content as the title.
+func titleOf(content string) string {
+	line := strings.SplitN(strings.TrimSpace(content), "\n", 2)[0]
+	return strings.TrimSpace(strings.TrimLeft(line, "# "))
+}
+
+func (s *Server) syncPost(externalID, content, format, editURL, readURL string) interface{} {
+	p := s.postByExternalID(externalID)
+	if p == nil {
+		p = &Post{ID: s.newID("post"), ExternalID: externalID, Published: true}
+		s.Posts[p.ID] = p
+	} else {
+		p.Version++
+	}
+	p.Content, p.Format, p.Title = content, format, titleOf(content)
+	if editURL != "" {
+		p.EditURL = editURL
+	}
+	if readURL != "" {
+		p.ReadURL = readURL
+	}
+	return s.postJSON(p)
+}
+
+func (s *Server) deletePost(id, externalID string) (interface{}, error) {
+	p, ok := s.Posts[id]
+	if id == "" {
+		p, ok = s.postByExternalID(externalID), s.postByExternalID(externalID) != nil
+	}
+	if !ok {
+		return nil, fmt.Errorf("Post not found")
+	}
+	delete(s.Posts, p.ID)
+	return map[string]string{"id": p.ID}, nil
+}
+
+func (s *Server) createPost(topicID string) interface{} {
+	p := &Post{ID: s.newID("post")}
+	if topicID != "" {
+		p.Topics = []string{topicID}
+	}
+	s.Posts[p.ID] = p
+	return map[string]string{"id": p.ID}
+}
+
+func (s *Server) updatePost(vars map[string]interface{}) (interface{}, error) {
+	id, _ := vars["id"].(string)
+	p, ok := s.Posts[id]
+	if !ok {
+		return nil, fmt.Errorf("Post %s not found", id)
+	}
+	if c, ok := vars["content"].(string); ok {
+		p.Content = c
+	}
+	if t, ok := vars["title"].(string); ok {
+		p.Title = t
+	}
+	if published, ok := vars["published"].(bool); ok {
+		p.Published = published
+	}
+	p.Version++
+	return s.postJSON(p), nil
+}
+
+func (s *Server) createTopic(name, description, parentID string) (interface{}, error) {
+	if parentID != "" {
+		if _, ok := s.Topics[parentID]; !ok {
+			return nil, fmt.Errorf("Topic %s not found", parentID)
+		}
+	}
+	id := s.addTopic(name, description, parentID)
+	return map[string]string{"id": id, "name": name, "description": description}, nil
+}
+
+func (s *Server) attach(topicID, postID string, add bool) (interface{}, error) {
+	t, ok := s.Topics[topicID]
+	if !ok {
+		return nil, fmt.Errorf("Topic %s not found", topicID)
+	}
+	p, ok := s.Posts[postID]
+	if !ok {
+		return nil, fmt.Errorf("Post %s not found", postID)
+	}
+	var topics []string
+	for _, id := range p.Topics {
+		if id != topicID {
+			topics = append(topics, id)
+		}
+	}
+	if add {
+		topics = append(topics, topicID)
+	}
+	p.Topics = topics
+	return map[string]string{"id": t.ID, "name": t.Name, "description": t.Description}, nil
+}
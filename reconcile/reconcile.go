@@ -0,0 +1,243 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/VEVO/slab-go/slab"
+)
+
+// ErrManualChanges is matched by the error Apply returns when the plan holds updates or deletions, which the client
+// does not support: they are left to be made in slab.
+var ErrManualChanges = errors.New("reconcile: updating or deleting topics is not supported, make these changes in slab")
+
+// Reconciler makes the topics of an organization match a Spec.
+type Reconciler struct {
+	Client *slab.Client
+	// Prune makes the plan include the deletion of the topics which are not part of the spec.
+	Prune bool
+	// Logf, when set, is called to report the changes applied.
+	Logf func(format string, args ...interface{})
+}
+
+// Kind is the kind of a change.
+type Kind string
+
+// The kinds of changes.
+const (
+	Create Kind = "create"
+	Update Kind = "update"
+	Delete Kind = "delete"
+)
+
+// Change is a modification to make to a topic.
+type Change struct {
+	Kind Kind
+	// Path is the path of topic names of the topic, with the names of the spec for the created and updated topics.
+	Path []string
+	// TopicID is the id of the updated or deleted topic.
+	TopicID string
+	// ParentID is the id of the parent of a topic to create when it already exists. It is empty for the top level
+	// topics and for the children of topics created by the same plan.
+	ParentID string
+	// Name is the new name of the updated topics whose case or spaces change, nil if the name does not change.
+	Name *string
+	// OldName is the name of the updated topic before the change.
+	OldName string
+	// Description is the description of the created topic, or the new description of an updated topic, nil if it
+	// does not change.
+	Description *string
+	// OldDescription is the description of the updated topic before the change.
+	OldDescription string
+}
+
+// Plan lists the changes to apply, the parents being created before their children and deleted after them.
+type Plan struct {
+	Changes []Change
+}
+
+// Plan compares the topics of the organization to the spec and returns the changes to apply, without applying them.
+func (r *Reconciler) Plan(ctx context.Context, spec *Spec) (*Plan, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	tree, err := r.Client.Topic.TreeContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{}
+	var deletions []Change
+	r.diff(plan, &deletions, tree, nil, nil, tree.Root(), spec.Topics)
+	if r.Prune {
+		// Orphans are not part of the hierarchy the spec describes
+		for _, o := range tree.Orphans() {
+			deleteSubtree(&deletions, tree, o)
+		}
+	}
+	plan.Changes = append(plan.Changes, deletions...)
+	return plan, nil
+}
+
+// diff compares the existing children of a topic, or the top level topics when parent is nil, to their spec.
+func (r *Reconciler) diff(plan *Plan, deletions *[]Change, tree *slab.TopicTree, parent *slab.Topic, path []string, existing []*slab.Topic, specs []TopicSpec) {
+	matched := make(map[string]bool)
+	for _, s := range specs {
+		name := strings.TrimSpace(s.Name)
+		p := append(append([]string(nil), path...), name)
+		var topic *slab.Topic
+		for _, t := range existing {
+			if slab.FoldTopicName(t.Name) == slab.FoldTopicName(name) {
+				topic = t
+				break
+			}
+		}
+		if topic == nil {
+			create := Change{Kind: Create, Path: p, Description: s.Description}
+			if parent != nil {
+				create.ParentID = parent.ID
+			}
+			plan.Changes = append(plan.Changes, create)
+			plan.createAll(p, s.Children)
+			continue
+		}
+
+		matched[topic.ID] = true
+		update := Change{Kind: Update, Path: p, TopicID: topic.ID, OldName: topic.Name, OldDescription: topic.Description}
+		if topic.Name != name {
+			update.Name = &name
+		}
+		if s.Description != nil && *s.Description != topic.Description {
+			update.Description = s.Description
+		}
+		if update.Name != nil || update.Description != nil {
+			plan.Changes = append(plan.Changes, update)
+		}
+		r.diff(plan, deletions, tree, topic, p, tree.Children(topic.ID), s.Children)
+	}
+	if r.Prune {
+		for _, t := range existing {
+			if !matched[t.ID] {
+				deleteSubtree(deletions, tree, t)
+			}
+		}
+	}
+}
+
+// createAll plans the creation of the given topics and of their children under the path of a created topic.
+func (p *Plan) createAll(path []string, specs []TopicSpec) {
+	for _, s := range specs {
+		child := append(append([]string(nil), path...), strings.TrimSpace(s.Name))
+		p.Changes = append(p.Changes, Change{Kind: Create, Path: child, Description: s.Description})
+		p.createAll(child, s.Children)
+	}
+}
+
+// deleteSubtree plans the deletion of a topic, after the deletion of its children.
+func deleteSubtree(deletions *[]Change, tree *slab.TopicTree, topic *slab.Topic) {
+	for _, c := range tree.Children(topic.ID) {
+		deleteSubtree(deletions, tree, c)
+	}
+	*deletions = append(*deletions, Change{Kind: Delete, Path: tree.Path(topic.ID), TopicID: topic.ID})
+}
+
+// Apply creates the topics of the plan in order. It stops at the first failure, the topics created until then being
+// kept.
+//
+// The client does not support renaming, describing or deleting a topic, so the updates and deletions of the plan are
+// not applied: once the topics are created, Apply returns an error matching ErrManualChanges which lists them.
+func (r *Reconciler) Apply(ctx context.Context, plan *Plan) error {
+	created := make(map[string]string) // path of the created topics -> id
+	var manual []string
+	for _, c := range plan.Changes {
+		path := pathString(c.Path)
+		switch c.Kind {
+		case Create:
+			parentID := c.ParentID
+			if len(c.Path) > 1 && parentID == "" {
+				parentID = created[pathString(c.Path[:len(c.Path)-1])]
+			}
+			description := ""
+			if c.Description != nil {
+				description = *c.Description
+			}
+			t, err := r.Client.Topic.CreateContext(ctx, c.Path[len(c.Path)-1], description, parentID)
+			if err != nil {
+				return fmt.Errorf("reconcile: creating %q: %w", path, err)
+			}
+			if t == nil {
+				return fmt.Errorf("reconcile: creating %q: no topic returned", path)
+			}
+			created[path] = t.ID
+			r.logf("created topic %s", path)
+		default:
+			manual = append(manual, string(c.Kind)+" "+path)
+		}
+	}
+	if len(manual) > 0 {
+		return fmt.Errorf("%w: %s", ErrManualChanges, strings.Join(manual, ", "))
+	}
+	return nil
+}
+
+func (r *Reconciler) logf(format string, args ...interface{}) {
+	if r.Logf != nil {
+		r.Logf(format, args...)
+	}
+}
+
+// Count returns the number of changes of the given kind.
+func (p *Plan) Count(k Kind) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Kind == k {
+			n++
+		}
+	}
+	return n
+}
+
+// Empty tells whether the topics already match the spec.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// WriteTo writes a summary of the plan to w, in the fashion of terraform: one line per change prefixed with `+` for
+// creations, `~` for updates and `-` for deletions, followed by the totals.
+func (p *Plan) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	for _, c := range p.Changes {
+		path := pathString(c.Path)
+		switch c.Kind {
+		case Create:
+			fmt.Fprintf(&sb, "  + %s\n", path)
+		case Update:
+			fmt.Fprintf(&sb, "  ~ %s\n", path)
+			if c.Name != nil {
+				fmt.Fprintf(&sb, "      name: %q -> %q\n", c.OldName, *c.Name)
+			}
+			if c.Description != nil {
+				fmt.Fprintf(&sb, "      description: %q -> %q\n", c.OldDescription, *c.Description)
+			}
+		case Delete:
+			fmt.Fprintf(&sb, "  - %s\n", path)
+		}
+	}
+	if p.Empty() {
+		sb.WriteString("No changes. The topics match the spec.\n")
+	} else {
+		fmt.Fprintf(&sb, "\nPlan: %d to create, %d to update, %d to delete.\n",
+			p.Count(Create), p.Count(Update), p.Count(Delete))
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// String returns the summary written by WriteTo.
+func (p *Plan) String() string {
+	var sb strings.Builder
+	_, _ = p.WriteTo(&sb)
+	return sb.String()
+}
@@ -0,0 +1,152 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VEVO/slab-go/internal/slabtest"
+	"github.com/VEVO/slab-go/slab"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSpec = `
+topics:
+  - name: Engineering
+    description: Everything about our services
+    children:
+      - name: Runbooks
+        children:
+          - name: Databases
+      - name: Architecture
+        description: Decisions
+  - name: Company
+  - name: CI/CD
+    children:
+      - name: Pipelines
+`
+
+func TestReconciler(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	eng := srv.AddTopic("engineering", "")
+	srv.AddTopic("Architecture", eng)
+	legacy := srv.AddTopic("Legacy", eng)
+	srv.AddTopic("Old runbooks", legacy)
+	srv.AddTopic("Company", "")
+	srv.AddTopic("Marketing", "")
+
+	spec, err := ParseSpec([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	r := &Reconciler{Client: srv.Client()}
+	plan, err := r.Plan(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, "  ~ Engineering\n"+
+		"      name: \"engineering\" -> \"Engineering\"\n"+
+		"      description: \"\" -> \"Everything about our services\"\n"+
+		"  + Engineering/Runbooks\n"+
+		"  + Engineering/Runbooks/Databases\n"+
+		"  ~ Engineering/Architecture\n"+
+		"      description: \"\" -> \"Decisions\"\n"+
+		"  + CI\\/CD\n"+
+		"  + CI\\/CD/Pipelines\n"+
+		"\n"+
+		"Plan: 4 to create, 2 to update, 0 to delete.\n", plan.String())
+	assert.Zero(t, srv.Calls["createTopic"], "planning must not change anything")
+
+	// The topics are created, the updates are left to be made in slab
+	var logs []string
+	r.Logf = func(format string, args ...interface{}) { logs = append(logs, format) }
+	err = r.Apply(context.Background(), plan)
+	assert.True(t, errors.Is(err, ErrManualChanges), "%v", err)
+	assert.EqualError(t, err, ErrManualChanges.Error()+": update Engineering, update Engineering/Architecture")
+	assert.Len(t, logs, 4)
+
+	tree, err := srv.Client().Topic.Tree()
+	assert.NoError(t, err)
+	assert.NotNil(t, tree.Find("Engineering/Runbooks/Databases", "/"))
+	assert.NotNil(t, tree.Find(`CI\/CD/Pipelines`, "/"))
+	assert.NotNil(t, tree.Find("Marketing", "/"), "topics are only deleted when pruning")
+
+	// Applying again creates nothing
+	plan, err = r.Plan(context.Background(), spec)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, plan.Count(Create))
+	assert.Equal(t, 2, plan.Count(Update))
+
+	// Pruning plans the deletion of the topics which are not described, the children first
+	r.Prune = true
+	plan, err = r.Plan(context.Background(), spec)
+	assert.NoError(t, err)
+	assert.Equal(t, "  ~ Engineering\n"+
+		"      name: \"engineering\" -> \"Engineering\"\n"+
+		"      description: \"\" -> \"Everything about our services\"\n"+
+		"  ~ Engineering/Architecture\n"+
+		"      description: \"\" -> \"Decisions\"\n"+
+		"  - engineering/Legacy/Old runbooks\n"+
+		"  - engineering/Legacy\n"+
+		"  - Marketing\n"+
+		"\n"+
+		"Plan: 0 to create, 2 to update, 3 to delete.\n", plan.String())
+	err = r.Apply(context.Background(), plan)
+	assert.True(t, errors.Is(err, ErrManualChanges), "%v", err)
+	assert.Len(t, srv.Topics, 10, "nothing is deleted")
+}
+
+func TestReconciler_Plan_Matching(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	srv.AddTopic("Company", "")
+
+	r := &Reconciler{Client: srv.Client(), Prune: true}
+	plan, err := r.Plan(context.Background(), &Spec{Topics: []TopicSpec{{Name: "Company"}}})
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty())
+	assert.Equal(t, "No changes. The topics match the spec.\n", plan.String())
+	assert.NoError(t, r.Apply(context.Background(), plan))
+}
+
+func TestReconciler_Apply_Error(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	r := &Reconciler{Client: srv.Client()}
+	plan := &Plan{Changes: []Change{
+		{Kind: Create, Path: []string{"A"}},
+		{Kind: Create, Path: []string{"Gone", "B"}, ParentID: "unknown"},
+		{Kind: Create, Path: []string{"C"}},
+	}}
+	err := r.Apply(context.Background(), plan)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `creating "Gone/B"`)
+	assert.True(t, slab.IsNotFound(err))
+	assert.Len(t, srv.Topics, 1, "the changes after the failure are not applied")
+}
+
+func TestReconciler_Apply_NoTopicCreated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"createTopic":null}}`))
+	}))
+	defer srv.Close()
+
+	r := &Reconciler{Client: slab.NewClient(nil, "token", slab.WithEndpoint(srv.URL))}
+	plan := &Plan{Changes: []Change{
+		{Kind: Create, Path: []string{"A"}},
+		{Kind: Create, Path: []string{"A", "B"}},
+	}}
+	err := r.Apply(context.Background(), plan)
+	assert.EqualError(t, err, `reconcile: creating "A": no topic returned`)
+}
+
+func TestReconciler_Plan_InvalidSpec(t *testing.T) {
+	r := &Reconciler{}
+	_, err := r.Plan(context.Background(), &Spec{Topics: []TopicSpec{{Name: " "}}})
+	assert.Error(t, err)
+}
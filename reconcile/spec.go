@@ -0,0 +1,102 @@
+// Package reconcile makes the topics of a slab organization match a description kept in a YAML or JSON file, so that
+// the topic hierarchy can be reviewed like code:
+//
+//	topics:
+//	  - name: Engineering
+//	    description: Everything about our services
+//	    children:
+//	      - name: Runbooks
+//	      - name: Architecture
+//	  - name: Company
+//
+// The topics are matched by name, ignoring the case like TopicService.AutoGenerate does. The missing topics are
+// created. The changes of descriptions and of the case of the names and, when Reconciler.Prune is set, the deletion
+// of the topics which are not described are planned too, but the client can not apply them: they are reported by
+// Reconciler.Apply, to be made in slab.
+//
+//	spec, err := reconcile.LoadSpecFile("topics.yaml")
+//	...
+//	r := &reconcile.Reconciler{Client: client}
+//	plan, err := r.Plan(ctx, spec)
+//	...
+//	fmt.Print(plan)
+//	err = r.Apply(ctx, plan)
+package reconcile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/VEVO/slab-go/slab"
+	"gopkg.in/yaml.v2"
+)
+
+// Spec is the desired hierarchy of topics.
+type Spec struct {
+	Topics []TopicSpec `yaml:"topics" json:"topics"`
+}
+
+// TopicSpec is a desired topic.
+type TopicSpec struct {
+	Name string `yaml:"name" json:"name"`
+	// Description is the desired description of the topic. When nil, the description is not managed: it is left as is
+	// on existing topics and empty on created ones.
+	Description *string     `yaml:"description,omitempty" json:"description,omitempty"`
+	Children    []TopicSpec `yaml:"children,omitempty" json:"children,omitempty"`
+}
+
+// ParseSpec decodes a spec from YAML or JSON, JSON being valid YAML. Unknown fields are rejected to catch the typos.
+func ParseSpec(data []byte) (*Spec, error) {
+	spec := &Spec{}
+	if err := yaml.UnmarshalStrict(data, spec); err != nil {
+		return nil, fmt.Errorf("reconcile: invalid spec: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// LoadSpecFile reads the spec stored in the given YAML or JSON file.
+func LoadSpecFile(filename string) (*Spec, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := ParseSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return spec, nil
+}
+
+// Validate checks that every topic has a name and that sibling topics have different names.
+func (s *Spec) Validate() error {
+	return validateTopics(nil, s.Topics)
+}
+
+func validateTopics(parent []string, topics []TopicSpec) error {
+	seen := make(map[string]bool, len(topics))
+	for _, t := range topics {
+		name := strings.TrimSpace(t.Name)
+		if name == "" {
+			return fmt.Errorf("reconcile: topic without name under %q", pathString(parent))
+		}
+		path := append(append([]string(nil), parent...), name)
+		key := slab.FoldTopicName(name)
+		if seen[key] {
+			return fmt.Errorf("reconcile: topic %q is described twice", pathString(path))
+		}
+		seen[key] = true
+		if err := validateTopics(path, t.Children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathString returns the path of topic names as displayed to the users.
+func pathString(path []string) string {
+	return slab.JoinTopicPath(path, "/")
+}
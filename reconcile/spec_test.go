@@ -0,0 +1,74 @@
+package reconcile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VEVO/slab-go/slab"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSpec(t *testing.T) {
+	want := &Spec{Topics: []TopicSpec{
+		{Name: "Engineering", Description: slab.String("Eng"), Children: []TopicSpec{{Name: "Runbooks"}}},
+		{Name: "Company", Description: slab.String("")},
+	}}
+
+	yamlSpec := `
+topics:
+  - name: Engineering
+    description: Eng
+    children:
+      - name: Runbooks
+  - name: Company
+    description: ""
+`
+	got, err := ParseSpec([]byte(yamlSpec))
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, want, got)
+
+	jsonSpec := `{"topics": [
+		{"name": "Engineering", "description": "Eng", "children": [{"name": "Runbooks"}]},
+		{"name": "Company", "description": ""}
+	]}`
+	got, err = ParseSpec([]byte(jsonSpec))
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestParseSpec_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":   "topics:\n  - name: A\n    descripton: typo\n",
+		"missing name":    "topics:\n  - name: A\n    children:\n      - description: B\n",
+		"duplicate name":  "topics:\n  - name: A\n    children:\n      - name: B\n      - name: ' b '\n",
+		"not a list":      "topics: A\n",
+		"invalid content": "{",
+	}
+	for name, spec := range tests {
+		_, err := ParseSpec([]byte(spec))
+		assert.Error(t, err, name)
+	}
+	_, err := ParseSpec([]byte("topics:\n  - name: A\n    children:\n      - name: B\n      - name: ' b '\n"))
+	assert.EqualError(t, err, `reconcile: topic "A/b" is described twice`)
+}
+
+func TestLoadSpecFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reconcile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "topics.yaml")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("topics:\n  - name: A\n"), 0644))
+
+	spec, err := LoadSpecFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, &Spec{Topics: []TopicSpec{{Name: "A"}}}, spec)
+
+	_, err = LoadSpecFile(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
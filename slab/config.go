@@ -0,0 +1,183 @@
+package slab
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ErrProfileNotFound is matched by the errors returned when the requested profile is not part of the configuration.
+var ErrProfileNotFound = errors.New("slab: profile not found")
+
+// Config is the content of the configuration file, which describes how to connect to one or several slab
+// organizations:
+//
+//	defaultProfile: work
+//	profiles:
+//	  work:
+//	    token: <API token>
+//	    defaultTopic: Engineering/Docs
+//	    timeout: 30s
+//	  community:
+//	    tokenCommand: [vault, kv, get, -field=token, secret/slab/community]
+//	    endpoint: https://slab-proxy.example.com/v1/graphql
+type Config struct {
+	// DefaultProfile is the name of the profile used when none is requested, "default" when empty.
+	DefaultProfile string `yaml:"defaultProfile,omitempty"`
+	// Profiles are the profiles of the configuration, by name.
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// Profile holds the settings of one organization. The API token is given by one of Token, TokenFile and
+// TokenCommand.
+type Profile struct {
+	// Token is the API token of the organization.
+	Token string `yaml:"token,omitempty"`
+	// TokenFile is the file the API token is read from, again when it changes. See FileToken.
+	TokenFile string `yaml:"tokenFile,omitempty"`
+	// TokenCommand is the command, with its arguments, printing the API token. Its output is cached until the API
+	// rejects the token. See CommandToken.
+	TokenCommand []string `yaml:"tokenCommand,omitempty"`
+	// Endpoint is the graphql endpoint to use, DefaultEndpoint when empty.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// DefaultTopic is the path of the topic, with "/" separators, the tools put the posts under when none is given.
+	DefaultTopic string `yaml:"defaultTopic,omitempty"`
+	// Timeout is the maximum duration of the HTTP requests, i.e. "30s". There is no timeout when zero.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// DefaultConfigPath returns the path of the configuration file: config.yaml in the slab directory of
+// $XDG_CONFIG_HOME, ~/.config by default.
+func DefaultConfigPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "slab", "config.yaml"), nil
+}
+
+// ParseConfig decodes a configuration from YAML. Unknown fields are rejected to catch the typos.
+func ParseConfig(data []byte) (*Config, error) {
+	c := &Config{}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, fmt.Errorf("slab: invalid configuration: %w", err)
+	}
+	for name, p := range c.Profiles {
+		if p == nil {
+			return nil, fmt.Errorf("slab: invalid configuration: profile %q is empty", name)
+		}
+		if n := p.tokenSettings(); n > 1 {
+			return nil, fmt.Errorf("slab: invalid configuration: profile %q has several of token, tokenFile and tokenCommand", name)
+		}
+	}
+	return c, nil
+}
+
+// tokenSettings returns the number of settings giving the token of the profile.
+func (p *Profile) tokenSettings() int {
+	n := 0
+	for _, set := range []bool{p.Token != "", p.TokenFile != "", len(p.TokenCommand) > 0} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// HasToken tells whether the profile gives an API token.
+func (p *Profile) HasToken() bool {
+	return p.tokenSettings() > 0
+}
+
+// TokenSource returns the source of the API token of the profile, nil when it has none.
+func (p *Profile) TokenSource() TokenSource {
+	switch {
+	case p.Token != "":
+		return StaticToken(p.Token)
+	case p.TokenFile != "":
+		return FileToken(p.TokenFile)
+	case len(p.TokenCommand) > 0:
+		return CacheToken(CommandToken(p.TokenCommand[0], p.TokenCommand[1:]...), 0)
+	}
+	return nil
+}
+
+// LoadConfig reads the configuration file of the given name, the one of DefaultConfigPath when empty. The error
+// matches os.ErrNotExist when the file does not exist.
+func LoadConfig(filename string) (*Config, error) {
+	if filename == "" {
+		var err error
+		if filename, err = DefaultConfigPath(); err != nil {
+			return nil, err
+		}
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	c, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return c, nil
+}
+
+// Profile returns the profile of the given name, or the default one when name is empty. The error matches
+// ErrProfileNotFound when there is no such profile.
+func (c *Config) Profile(name string) (*Profile, error) {
+	name = c.profileName(name)
+	if p, ok := c.Profiles[name]; ok {
+		return p, nil
+	}
+	names := make([]string, 0, len(c.Profiles))
+	for n := range c.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("%w: %q, the profiles are: %s", ErrProfileNotFound, name, strings.Join(names, ", "))
+}
+
+// profileName returns the name of the profile used when the given one is requested.
+func (c *Config) profileName(name string) string {
+	switch {
+	case name != "":
+		return name
+	case c.DefaultProfile != "":
+		return c.DefaultProfile
+	default:
+		return "default"
+	}
+}
+
+// NewClientFromConfig creates a client for the given profile of the configuration. When profile is empty, the
+// profile named by the SLAB_PROFILE environment variable is used, or else the default profile of the configuration.
+// The options are applied after the ones derived from the profile, so that they can override them.
+func NewClientFromConfig(c *Config, profile string, opts ...ClientOption) (*Client, error) {
+	if profile == "" {
+		profile = os.Getenv("SLAB_PROFILE")
+	}
+	p, err := c.Profile(profile)
+	if err != nil {
+		return nil, err
+	}
+	if !p.HasToken() {
+		return nil, fmt.Errorf("slab: the profile %q has no token", c.profileName(profile))
+	}
+	profileOpts := []ClientOption{WithTokenSource(p.TokenSource())}
+	if p.Endpoint != "" {
+		profileOpts = append(profileOpts, WithEndpoint(p.Endpoint))
+	}
+	return NewClient(&http.Client{Timeout: p.Timeout}, p.Token, append(profileOpts, opts...)...), nil
+}
@@ -0,0 +1,208 @@
+package slab
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+defaultProfile: work
+profiles:
+  work:
+    token: work_token
+    defaultTopic: Engineering/Docs
+    timeout: 45s
+  community:
+    token: community_token
+    endpoint: https://slab-proxy.example.com/v1/graphql
+`
+
+func TestParseConfig(t *testing.T) {
+	c, err := ParseConfig([]byte(testConfig))
+	if !assert.NoError(t, err) {
+		return
+	}
+	expected := &Config{
+		DefaultProfile: "work",
+		Profiles: map[string]*Profile{
+			"work":      {Token: "work_token", DefaultTopic: "Engineering/Docs", Timeout: 45 * time.Second},
+			"community": {Token: "community_token", Endpoint: "https://slab-proxy.example.com/v1/graphql"},
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("ParseConfig returned %+v, expected %+v", c, expected)
+	}
+
+	for _, invalid := range []string{
+		"profiles:\n  work:\n    tokn: typo\n",
+		"profiles:\n  work:\n",
+		"profiles:\n  work:\n    timeout: soon\n",
+		"profiles: [work]\n",
+		"profiles:\n  work:\n    token: abc\n    tokenFile: /run/secrets/slab\n",
+	} {
+		_, err := ParseConfig([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestProfile_TokenSource(t *testing.T) {
+	c, err := ParseConfig([]byte(`
+profiles:
+  static:
+    token: abc
+  file:
+    tokenFile: /run/secrets/slab
+  vault:
+    tokenCommand: [vault, kv, get, -field=token, secret/slab]
+  none:
+    endpoint: http://localhost
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, StaticToken("abc"), c.Profiles["static"].TokenSource())
+	assert.Equal(t, FileToken("/run/secrets/slab"), c.Profiles["file"].TokenSource())
+	if cached, ok := c.Profiles["vault"].TokenSource().(*cachedToken); assert.True(t, ok) {
+		assert.Equal(t, CommandToken("vault", "kv", "get", "-field=token", "secret/slab"), cached.src)
+		assert.Zero(t, cached.ttl)
+	}
+	assert.False(t, c.Profiles["none"].HasToken())
+	assert.Nil(t, c.Profiles["none"].TokenSource())
+}
+
+func TestConfig_Profile(t *testing.T) {
+	c, err := ParseConfig([]byte(testConfig))
+	if !assert.NoError(t, err) {
+		return
+	}
+	p, err := c.Profile("")
+	assert.NoError(t, err)
+	assert.Equal(t, "work_token", p.Token)
+
+	p, err = c.Profile("community")
+	assert.NoError(t, err)
+	assert.Equal(t, "community_token", p.Token)
+
+	_, err = c.Profile("personal")
+	assert.True(t, errors.Is(err, ErrProfileNotFound))
+	assert.EqualError(t, err, `slab: profile not found: "personal", the profiles are: community, work`)
+
+	// Without default profile, the profile named default is used
+	c.DefaultProfile = ""
+	_, err = c.Profile("")
+	assert.EqualError(t, err, `slab: profile not found: "default", the profiles are: community, work`)
+	c.Profiles["default"] = &Profile{Token: "default_token"}
+	p, err = c.Profile("")
+	assert.NoError(t, err)
+	assert.Equal(t, "default_token", p.Token)
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slab-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = LoadConfig(filepath.Join(dir, "missing.yaml"))
+	assert.True(t, errors.Is(err, os.ErrNotExist), "%v", err)
+
+	filename := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("profiles: {default: {token: abc}}\n"), 0600))
+	c, err := LoadConfig(filename)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "abc", c.Profiles["default"].Token)
+	}
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("profile: {}\n"), 0600))
+	_, err = LoadConfig(filename)
+	assert.Contains(t, err.Error(), filename+": slab: invalid configuration")
+}
+
+func TestDefaultConfigPath(t *testing.T) {
+	old, set := os.LookupEnv("XDG_CONFIG_HOME")
+	defer func() {
+		if set {
+			os.Setenv("XDG_CONFIG_HOME", old)
+		} else {
+			os.Unsetenv("XDG_CONFIG_HOME")
+		}
+	}()
+
+	os.Setenv("XDG_CONFIG_HOME", filepath.FromSlash("/etc/xdg"))
+	p, err := DefaultConfigPath()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("/etc/xdg/slab/config.yaml"), p)
+
+	os.Unsetenv("XDG_CONFIG_HOME")
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	p, err = DefaultConfigPath()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".config", "slab", "config.yaml"), p)
+}
+
+func TestNewClientFromConfig(t *testing.T) {
+	var gotToken string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotToken = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"data": {"organization": {"id": "org1"}}}`))
+	}))
+	defer srv.Close()
+
+	c := &Config{
+		DefaultProfile: "work",
+		Profiles: map[string]*Profile{
+			"work":      {Token: "work_token", Endpoint: srv.URL, Timeout: time.Minute},
+			"community": {Token: "community_token", Endpoint: srv.URL},
+			"empty":     {Endpoint: srv.URL},
+		},
+	}
+
+	client, err := NewClientFromConfig(c, "")
+	if assert.NoError(t, err) {
+		_, err = client.Organization.Get()
+		assert.NoError(t, err)
+		assert.Equal(t, "work_token", gotToken)
+		assert.Equal(t, srv.URL, client.endpoint)
+	}
+
+	old, set := os.LookupEnv("SLAB_PROFILE")
+	defer func() {
+		if set {
+			os.Setenv("SLAB_PROFILE", old)
+		} else {
+			os.Unsetenv("SLAB_PROFILE")
+		}
+	}()
+	os.Setenv("SLAB_PROFILE", "community")
+	client, err = NewClientFromConfig(c, "")
+	if assert.NoError(t, err) {
+		_, err = client.Organization.Get()
+		assert.NoError(t, err)
+		assert.Equal(t, "community_token", gotToken)
+	}
+
+	// The options override the profile
+	client, err = NewClientFromConfig(c, "work", WithEndpoint("http://localhost:1"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "http://localhost:1", client.endpoint)
+	}
+
+	_, err = NewClientFromConfig(c, "empty")
+	assert.EqualError(t, err, `slab: the profile "empty" has no token`)
+
+	_, err = NewClientFromConfig(c, "personal")
+	assert.True(t, errors.Is(err, ErrProfileNotFound))
+}
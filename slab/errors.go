@@ -0,0 +1,185 @@
+package slab
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrNotFound is matched by errors.Is when the API reports that the requested object does not exist.
+	ErrNotFound = errors.New("slab: not found")
+	// ErrUnauthorized is matched by errors.Is when the API rejects the token or the token lacks the permissions
+	// required by the query.
+	ErrUnauthorized = errors.New("slab: unauthorized")
+	// ErrRateLimited is matched by errors.Is when the API refuses the request because too many were sent.
+	ErrRateLimited = errors.New("slab: rate limited")
+	// ErrVersionConflict is matched by errors.Is when a post was modified since the version an update was based on.
+	ErrVersionConflict = errors.New("slab: version conflict")
+)
+
+// IsNotFound reports whether err means that the requested object does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsUnauthorized reports whether err means that the token was rejected or is not allowed to run the query.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsRateLimited reports whether err means that the API is throttling the client.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// Location is a position in the graphql query an error relates to.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is a single error from the `errors` array of a graphql response.
+type Error struct {
+	Message string `json:"message"`
+	// Path is the path of the response field that failed, made of field names (string) and list indexes (float64).
+	Path       []interface{}          `json:"path,omitempty"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Path) == 0 {
+		return "graphql: " + e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		path[i] = fmt.Sprint(p)
+	}
+	return fmt.Sprintf("graphql: %s (path: %s)", e.Message, strings.Join(path, "."))
+}
+
+// Code returns the `code` extension of the error if any, as most graphql servers use it to classify errors.
+func (e *Error) Code() string {
+	if code, ok := e.Extensions["code"].(string); ok {
+		return code
+	}
+	return ""
+}
+
+// Is allows errors.Is to match the error against ErrNotFound, ErrUnauthorized and ErrRateLimited based on its
+// code or, when the API does not provide one, on its message.
+func (e *Error) Is(target error) bool {
+	code := strings.ToUpper(e.Code())
+	msg := strings.ToLower(e.Message)
+	switch target {
+	case ErrNotFound:
+		return code == "NOT_FOUND" || strings.Contains(msg, "not found")
+	case ErrUnauthorized:
+		return code == "UNAUTHENTICATED" || code == "UNAUTHORIZED" || code == "FORBIDDEN" ||
+			strings.Contains(msg, "unauthorized") || strings.Contains(msg, "not authorized") ||
+			strings.Contains(msg, "unauthenticated")
+	case ErrRateLimited:
+		return code == "RATE_LIMITED" || code == "TOO_MANY_REQUESTS" || strings.Contains(msg, "rate limit")
+	}
+	return false
+}
+
+// Errors is the list of errors returned in a graphql response.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	switch len(e) {
+	case 0:
+		return "graphql: unknown error"
+	case 1:
+		return e[0].Error()
+	}
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the errors matches target.
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As allows errors.As to extract the first *Error of the list.
+func (e Errors) As(target interface{}) bool {
+	if t, ok := target.(**Error); ok && len(e) > 0 {
+		*t = e[0]
+		return true
+	}
+	return false
+}
+
+// HTTPError is returned when the API answers with a non-successful HTTP status.
+type HTTPError struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Errors holds the graphql errors if the body contained any.
+	Errors Errors
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("slab: unexpected HTTP status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if len(e.Errors) > 0 {
+		msg += ": " + e.Errors.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the graphql errors contained in the response if any.
+func (e *HTTPError) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e.Errors
+}
+
+// Is matches the HTTP status against ErrNotFound, ErrUnauthorized and ErrRateLimited.
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// VersionConflictError is returned by PostService.Update when the post was modified since the expected version.
+type VersionConflictError struct {
+	PostID   string
+	Expected int
+	// Actual is the version the post was at when the conflict was detected: before the update or, when Applied is
+	// set, the version returned by the update.
+	Actual int
+	// Applied is set when the conflict was only detected from the version returned by the update: the changes were
+	// applied on top of the other modification, which they may have overwritten.
+	Applied bool
+}
+
+func (e *VersionConflictError) Error() string {
+	if e.Applied {
+		return fmt.Sprintf("slab: post %s is at version %d after an update based on version %d", e.PostID, e.Actual, e.Expected)
+	}
+	return fmt.Sprintf("slab: post %s is at version %d, expected version %d", e.PostID, e.Actual, e.Expected)
+}
+
+// Is matches ErrVersionConflict.
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
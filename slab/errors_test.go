@@ -0,0 +1,144 @@
+package slab
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo_GraphqlErrors(t *testing.T) {
+	expectedResp := `{"data":{"post":null},"errors":[{
+		"message":"Post not found",
+		"path":["post"],
+		"locations":[{"line":3,"column":3}],
+		"extensions":{"code":"NOT_FOUND"}
+	}]}`
+	c, _, teardown := setup(t, expectedResp)
+	defer teardown()
+
+	_, err := c.Post.Get("abc123")
+	if err == nil {
+		t.Fatal("Expecting an error, got none")
+	}
+
+	want := Errors{{
+		Message:    "Post not found",
+		Path:       []interface{}{"post"},
+		Locations:  []Location{{Line: 3, Column: 3}},
+		Extensions: map[string]interface{}{"code": "NOT_FOUND"},
+	}}
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expecting an Errors, got: %#v", err)
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("Get returned: %#v\nwant %#v", errs, want)
+	}
+
+	var first *Error
+	if assert.True(t, errors.As(err, &first)) {
+		assert.Equal(t, "NOT_FOUND", first.Code())
+	}
+	assert.Equal(t, "graphql: Post not found (path: post)", err.Error())
+	assert.True(t, IsNotFound(err))
+	assert.False(t, IsUnauthorized(err))
+	assert.False(t, IsRateLimited(err))
+}
+
+func TestDo_MultipleGraphqlErrors(t *testing.T) {
+	expectedResp := `{"errors":[
+		{"message":"Field 'foo' is missing","locations":[{"line":1,"column":2}]},
+		{"message":"You are not authorized to access this resource"}
+	]}`
+	c, _, teardown := setup(t, expectedResp)
+	defer teardown()
+
+	_, err := c.Topic.Get("abc123")
+	var errs Errors
+	if assert.True(t, errors.As(err, &errs)) {
+		assert.Len(t, errs, 2)
+	}
+	assert.Equal(t, "graphql: Field 'foo' is missing; graphql: You are not authorized to access this resource", err.Error())
+	assert.True(t, IsUnauthorized(err))
+	assert.False(t, IsNotFound(err))
+}
+
+func TestDo_HTTPErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		notFound     bool
+		unauthorized bool
+		rateLimited  bool
+	}{
+		{name: "not found", status: http.StatusNotFound, body: "not here", notFound: true},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: `{"errors":[{"message":"invalid token"}]}`, unauthorized: true},
+		{name: "forbidden", status: http.StatusForbidden, body: "", unauthorized: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, body: "slow down", rateLimited: true},
+		{name: "bad gateway", status: http.StatusBadGateway, body: "<html>oops</html>"},
+		{name: "server error with valid json", status: http.StatusInternalServerError, body: `{"data":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, teardown := setupServer(t, testServer{status: tt.status, responses: []string{tt.body}})
+			defer teardown()
+
+			_, err := c.User.Get("abc123")
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("Expecting an *HTTPError, got: %#v", err)
+			}
+			assert.Equal(t, tt.status, httpErr.StatusCode)
+			assert.Equal(t, tt.body, string(httpErr.Body))
+			assert.Equal(t, tt.notFound, IsNotFound(err))
+			assert.Equal(t, tt.unauthorized, IsUnauthorized(err))
+			assert.Equal(t, tt.rateLimited, IsRateLimited(err))
+		})
+	}
+}
+
+func TestDo_HTTPErrorWithGraphqlErrors(t *testing.T) {
+	c, _, teardown := setupServer(t, testServer{
+		status:    http.StatusBadRequest,
+		responses: []string{`{"errors":[{"message":"Argument 'id' has an invalid value"}]}`},
+	})
+	defer teardown()
+
+	_, err := c.Post.Get("abc123")
+	var httpErr *HTTPError
+	assert.True(t, errors.As(err, &httpErr))
+	var gqlErr *Error
+	if assert.True(t, errors.As(err, &gqlErr)) {
+		assert.Equal(t, "Argument 'id' has an invalid value", gqlErr.Message)
+	}
+	assert.Equal(t, "slab: unexpected HTTP status 400 Bad Request: graphql: Argument 'id' has an invalid value", err.Error())
+}
+
+func TestError_Is(t *testing.T) {
+	tests := []struct {
+		err    *Error
+		target error
+		want   bool
+	}{
+		{&Error{Message: "whatever", Extensions: map[string]interface{}{"code": "not_found"}}, ErrNotFound, true},
+		{&Error{Message: "Topic not found"}, ErrNotFound, true},
+		{&Error{Message: "whatever", Extensions: map[string]interface{}{"code": "UNAUTHENTICATED"}}, ErrUnauthorized, true},
+		{&Error{Message: "whatever", Extensions: map[string]interface{}{"code": "FORBIDDEN"}}, ErrUnauthorized, true},
+		{&Error{Message: "Unauthorized"}, ErrUnauthorized, true},
+		{&Error{Message: "whatever", Extensions: map[string]interface{}{"code": "RATE_LIMITED"}}, ErrRateLimited, true},
+		{&Error{Message: "Rate limit exceeded"}, ErrRateLimited, true},
+		{&Error{Message: "Something broke"}, ErrNotFound, false},
+		{&Error{Message: "Something broke"}, ErrUnauthorized, false},
+		{&Error{Message: "Something broke"}, ErrRateLimited, false},
+		{&Error{Message: "Something broke", Extensions: map[string]interface{}{"code": 42}}, ErrNotFound, false},
+	}
+	for _, tt := range tests {
+		if got := errors.Is(tt.err, tt.target); got != tt.want {
+			t.Errorf("errors.Is(%v, %v) returned %v, want %v", tt.err, tt.target, got, tt.want)
+		}
+	}
+}
@@ -23,6 +23,11 @@ type Organization struct {
 // Note that it does not fetch the hierarchies of the topics or the content of the posts
 // or the user details to avoid issues with too much data
 func (o *OrganizationService) Get() (*Organization, error) {
+	return o.GetContext(context.Background())
+}
+
+// GetContext is like Get but uses the given context for the request.
+func (o *OrganizationService) GetContext(ctx context.Context) (*Organization, error) {
 	query := `{
 		organization {
 			id,
@@ -38,9 +43,8 @@ func (o *OrganizationService) Get() (*Organization, error) {
 	var resp struct {
 		Organization *Organization `json:"organization"`
 	}
-	if err := o.client.Do(context.Background(), query, nil, &resp); err != nil {
+	if err := o.client.Do(ctx, query, nil, &resp); err != nil {
 		return nil, err
 	}
 	return resp.Organization, nil
-
 }
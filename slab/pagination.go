@@ -0,0 +1,268 @@
+package slab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultPageSize is the number of items fetched per request by the iterators when ListOptions.PageSize is not set.
+const DefaultPageSize = 50
+
+// ListOptions configures the iterators returned by the Iterate methods of the services.
+type ListOptions struct {
+	// PageSize is the number of items whose details are fetched per request.
+	PageSize int
+}
+
+func (o *ListOptions) pageSize() int {
+	if o == nil || o.PageSize <= 0 {
+		return DefaultPageSize
+	}
+	return o.PageSize
+}
+
+// The fields fetched for each item by the iterators. They match the ones of the List methods.
+const (
+	postListFields  = `id, title, version, insertedAt, publishedAt, updatedAt`
+	topicListFields = `id, name, description, hierarchy, parent{id}, ancestors{id}, children{id}, insertedAt, updatedAt`
+	userListFields  = `id, name, description, email, title, type, avatar{original, thumb}, insertedAt, deactivatedAt, updatedAt`
+)
+
+// pager fetches the items of an organization collection in chunks.
+//
+// The slab API does not offer cursors on the organization collections, so the pager first lists the IDs of the whole
+// collection, which is cheap, then fetches the details of PageSize items at a time using aliased queries.
+type pager struct {
+	ctx        context.Context
+	client     *Client
+	collection string // name of the collection in the organization, i.e. "posts"
+	field      string // name of the query fetching a single item, i.e. "post"
+	fields     string // fields to fetch for each item
+	pageSize   int
+
+	ids    []string
+	listed bool
+	page   []json.RawMessage
+	err    error
+}
+
+// listIDs fetches the IDs of all the items of the collection.
+func (p *pager) listIDs() error {
+	query := fmt.Sprintf(`{ organization { %s { id } } }`, p.collection)
+	var resp struct {
+		Organization map[string][]struct {
+			ID string `json:"id"`
+		} `json:"organization"`
+	}
+	if err := p.client.Do(p.ctx, query, nil, &resp); err != nil {
+		return err
+	}
+	for _, item := range resp.Organization[p.collection] {
+		p.ids = append(p.ids, item.ID)
+	}
+	return nil
+}
+
+// next returns the raw json of the items of the next page. It returns false once all the items have been fetched
+// or when an error occurred, which is then available in p.err.
+// Items removed since the IDs were listed are skipped, whether the API returns null for them or a not found error,
+// so a page may be empty.
+func (p *pager) next() ([]json.RawMessage, bool) {
+	if p.err != nil {
+		return nil, false
+	}
+	if !p.listed {
+		p.listed = true
+		if p.err = p.listIDs(); p.err != nil {
+			return nil, false
+		}
+	}
+	if len(p.ids) == 0 {
+		return nil, false
+	}
+
+	n := p.pageSize
+	if n > len(p.ids) {
+		n = len(p.ids)
+	}
+	page := p.ids[:n]
+
+	params := make([]string, n)
+	selections := make([]string, n)
+	vars := make(map[string]interface{}, n)
+	for i, id := range page {
+		params[i] = fmt.Sprintf("$id%d: ID", i)
+		selections[i] = fmt.Sprintf("item%d: %s(id: $id%d){ %s }", i, p.field, i, p.fields)
+		vars[fmt.Sprintf("id%d", i)] = id
+	}
+	query := fmt.Sprintf("query (%s){\n%s\n}", strings.Join(params, ", "), strings.Join(selections, "\n"))
+
+	var resp map[string]json.RawMessage
+	if err := p.client.Do(p.ctx, query, vars, &resp); err != nil && !onlyMissingItems(err) {
+		p.err = err
+		return nil, false
+	}
+	p.ids = p.ids[n:]
+
+	items := make([]json.RawMessage, 0, n)
+	for i := range page {
+		raw := resp[fmt.Sprintf("item%d", i)]
+		if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+			continue
+		}
+		items = append(items, raw)
+	}
+	return items, true
+}
+
+// onlyMissingItems tells whether err only reports items of the page which do not exist anymore, in which case the
+// others were returned.
+func onlyMissingItems(err error) bool {
+	errs, ok := err.(Errors)
+	if !ok || len(errs) == 0 {
+		return false
+	}
+	for _, e := range errs {
+		if len(e.Path) == 0 || !errors.Is(e, ErrNotFound) {
+			return false
+		}
+		if alias, ok := e.Path[0].(string); !ok || !strings.HasPrefix(alias, "item") {
+			return false
+		}
+	}
+	return true
+}
+
+// nextItem decodes the next item into v, fetching the next page when needed. It returns false once all the items
+// have been fetched or when an error occurred, which is then available in p.err.
+func (p *pager) nextItem(v interface{}) bool {
+	for len(p.page) == 0 {
+		raws, ok := p.next()
+		if !ok {
+			return false
+		}
+		p.page = raws
+	}
+	raw := p.page[0]
+	p.page = p.page[1:]
+	if err := json.Unmarshal(raw, v); err != nil {
+		p.err = err
+		return false
+	}
+	return true
+}
+
+// PostIterator iterates over the posts of the organization, fetching them page by page.
+//
+//	it := client.Post.Iterate(ctx, nil)
+//	for it.Next() {
+//		fmt.Println(it.Post().Title)
+//	}
+//	if err := it.Err(); err != nil {
+//		// handle error
+//	}
+type PostIterator struct {
+	pager *pager
+	cur   *Post
+}
+
+// Iterate returns an iterator over all the posts of the organization. As with List, the content of the posts is
+// not fetched.
+func (p *PostService) Iterate(ctx context.Context, opts *ListOptions) *PostIterator {
+	return &PostIterator{pager: &pager{
+		ctx: ctx, client: p.client, collection: "posts", field: "post", fields: postListFields, pageSize: opts.pageSize(),
+	}}
+}
+
+// Next advances to the next post. It returns false when there are no more posts or when an error occurred.
+func (it *PostIterator) Next() bool {
+	var post Post
+	if !it.pager.nextItem(&post) {
+		it.cur = nil
+		return false
+	}
+	it.cur = &post
+	return true
+}
+
+// Post returns the current post. It is only valid after a call to Next that returned true.
+func (it *PostIterator) Post() *Post {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *PostIterator) Err() error {
+	return it.pager.err
+}
+
+// TopicIterator iterates over the topics of the organization, fetching them page by page.
+type TopicIterator struct {
+	pager *pager
+	cur   *Topic
+}
+
+// Iterate returns an iterator over all the topics of the organization, with the same details as List.
+func (t *TopicService) Iterate(ctx context.Context, opts *ListOptions) *TopicIterator {
+	return &TopicIterator{pager: &pager{
+		ctx: ctx, client: t.client, collection: "topics", field: "topic", fields: topicListFields, pageSize: opts.pageSize(),
+	}}
+}
+
+// Next advances to the next topic. It returns false when there are no more topics or when an error occurred.
+func (it *TopicIterator) Next() bool {
+	var topic Topic
+	if !it.pager.nextItem(&topic) {
+		it.cur = nil
+		return false
+	}
+	it.cur = &topic
+	return true
+}
+
+// Topic returns the current topic. It is only valid after a call to Next that returned true.
+func (it *TopicIterator) Topic() *Topic {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *TopicIterator) Err() error {
+	return it.pager.err
+}
+
+// UserIterator iterates over the users of the organization, fetching them page by page.
+type UserIterator struct {
+	pager *pager
+	cur   *User
+}
+
+// Iterate returns an iterator over all the users of the organization, with the same details as List.
+func (u *UserService) Iterate(ctx context.Context, opts *ListOptions) *UserIterator {
+	return &UserIterator{pager: &pager{
+		ctx: ctx, client: u.client, collection: "users", field: "user", fields: userListFields, pageSize: opts.pageSize(),
+	}}
+}
+
+// Next advances to the next user. It returns false when there are no more users or when an error occurred.
+func (it *UserIterator) Next() bool {
+	var user User
+	if !it.pager.nextItem(&user) {
+		it.cur = nil
+		return false
+	}
+	it.cur = &user
+	return true
+}
+
+// User returns the current user. It is only valid after a call to Next that returned true.
+func (it *UserIterator) User() *User {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *UserIterator) Err() error {
+	return it.pager.err
+}
@@ -0,0 +1,192 @@
+package slab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// paginatedHandler answers the requests of the iterators with the given items by collection name ("posts",
+// "topics"...), for setupServer.
+func paginatedHandler(collection string, items []map[string]interface{}) func(req graphqlRequest) interface{} {
+	byID := make(map[string]map[string]interface{})
+	for _, item := range items {
+		byID[item["id"].(string)] = item
+	}
+
+	return func(req graphqlRequest) interface{} {
+		data := make(map[string]interface{})
+		var errs []map[string]interface{}
+		if strings.Contains(req.Query, "organization") {
+			ids := make([]map[string]string, 0, len(items))
+			for _, item := range items {
+				ids = append(ids, map[string]string{"id": item["id"].(string)})
+			}
+			// Unknown IDs simulate items removed between the listing and the fetching of the details
+			ids = append(ids, map[string]string{"id": "deleted"})
+			data["organization"] = map[string]interface{}{collection: ids}
+		} else {
+			for i := 0; i < len(req.Variables); i++ {
+				id := req.Variables[fmt.Sprintf("id%d", i)].(string)
+				alias := fmt.Sprintf("item%d", i)
+				item, ok := byID[id]
+				if ok {
+					data[alias] = item
+				} else {
+					// Like slab, the missing items are null and reported as not found
+					data[alias] = nil
+					errs = append(errs, map[string]interface{}{
+						"message": "Not found", "path": []string{alias}, "extensions": map[string]string{"code": "NOT_FOUND"},
+					})
+				}
+			}
+		}
+		body := map[string]interface{}{"data": data}
+		if len(errs) > 0 {
+			body["errors"] = errs
+		}
+		return body
+	}
+}
+
+// pageSizes returns the number of items fetched by each of the pages requested to the server.
+func pageSizes(calls *serverCalls) []int {
+	var sizes []int
+	for _, req := range calls.requests() {
+		if !strings.Contains(req.Query, "organization") {
+			sizes = append(sizes, len(req.Variables))
+		}
+	}
+	return sizes
+}
+
+func TestPostService_Iterate(t *testing.T) {
+	var items []map[string]interface{}
+	for i := 0; i < 7; i++ {
+		items = append(items, map[string]interface{}{"id": fmt.Sprintf("postid%d", i), "title": fmt.Sprintf("Post %d", i), "version": i})
+	}
+	c, calls, teardown := setupServer(t, testServer{handler: paginatedHandler("posts", items)})
+	defer teardown()
+
+	it := c.Post.Iterate(context.Background(), &ListOptions{PageSize: 3})
+	var got []Post
+	for it.Next() {
+		got = append(got, *it.Post())
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("Expecting no error, got: %v", err)
+	}
+
+	assert.Len(t, got, 7)
+	for i, p := range got {
+		assert.Equal(t, fmt.Sprintf("postid%d", i), p.ID)
+		assert.Equal(t, fmt.Sprintf("Post %d", i), p.Title)
+		assert.Equal(t, i, p.Version)
+	}
+	// 7 posts + 1 deleted one in pages of 3
+	assert.Equal(t, []int{3, 3, 2}, pageSizes(calls))
+	assert.Nil(t, it.Post())
+	assert.False(t, it.Next())
+}
+
+func TestTopicService_Iterate(t *testing.T) {
+	items := []map[string]interface{}{
+		{"id": "abc123", "name": "Topic A", "parent": nil},
+		{"id": "zzzblabla", "name": "Topic B", "parent": map[string]string{"id": "abc123"}},
+	}
+	c, calls, teardown := setupServer(t, testServer{handler: paginatedHandler("topics", items)})
+	defer teardown()
+
+	it := c.Topic.Iterate(context.Background(), nil)
+	var got []Topic
+	for it.Next() {
+		got = append(got, *it.Topic())
+	}
+	assert.NoError(t, it.Err())
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, "Topic A", got[0].Name)
+		assert.Equal(t, &Topic{ID: "abc123"}, got[1].Parent)
+	}
+	assert.Equal(t, []int{3}, pageSizes(calls))
+}
+
+func TestUserService_Iterate(t *testing.T) {
+	items := []map[string]interface{}{
+		{"id": "abc123", "name": "Homer S.", "email": "homer@example.com"},
+	}
+	c, _, teardown := setupServer(t, testServer{handler: paginatedHandler("users", items)})
+	defer teardown()
+
+	it := c.User.Iterate(context.Background(), &ListOptions{PageSize: 1})
+	var got []User
+	for it.Next() {
+		got = append(got, *it.User())
+	}
+	assert.NoError(t, it.Err())
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "homer@example.com", got[0].Email)
+	}
+}
+
+func TestPostService_Iterate_Error(t *testing.T) {
+	c, _, teardown := setupServer(t, testServer{status: http.StatusInternalServerError, responses: []string{"oops"}})
+	defer teardown()
+
+	it := c.Post.Iterate(context.Background(), nil)
+	assert.False(t, it.Next())
+	var httpErr *HTTPError
+	if assert.True(t, errors.As(it.Err(), &httpErr)) {
+		assert.Equal(t, http.StatusInternalServerError, httpErr.StatusCode)
+	}
+	assert.False(t, it.Next())
+}
+
+func TestPostService_Iterate_ItemError(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		errors string
+		ok     bool
+	}{
+		{"deleted item without error", ``, true},
+		{"deleted item", `[{"message": "Post not found", "path": ["item1"], "extensions": {"code": "NOT_FOUND"}}]`, true},
+		{"failed item", `[{"message": "Internal error", "path": ["item1"], "extensions": {"code": "INTERNAL"}}]`, false},
+		{"not found outside of the items", `[{"message": "Not found", "path": ["organization"]}]`, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				if strings.Contains(string(body), "organization") {
+					fmt.Fprint(w, `{"data": {"organization": {"posts": [{"id": "a"}, {"id": "b"}]}}}`)
+					return
+				}
+				resp := `{"data": {"item0": {"id": "a", "title": "A"}, "item1": null}`
+				if tt.errors != "" {
+					resp += `, "errors": ` + tt.errors
+				}
+				fmt.Fprint(w, resp+"}")
+			}))
+			defer srv.Close()
+			c := NewClient(&http.Client{}, "dummy_token", WithEndpoint(srv.URL))
+
+			it := c.Post.Iterate(context.Background(), nil)
+			var got []string
+			for it.Next() {
+				got = append(got, it.Post().ID)
+			}
+			if tt.ok {
+				assert.NoError(t, it.Err())
+				assert.Equal(t, []string{"a"}, got)
+			} else {
+				assert.Error(t, it.Err())
+				assert.Empty(t, got)
+			}
+		})
+	}
+}
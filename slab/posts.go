@@ -2,6 +2,9 @@ package slab
 
 import (
 	"context"
+	"errors"
+
+	"github.com/VEVO/slab-go/delta"
 )
 
 // PostService is an implementation of the service to interact with the posts
@@ -18,9 +21,23 @@ type Post struct {
 	UpdatedAt   *DateTime `json:"updatedAt,omitempty"`
 }
 
+// ParseContent decodes the content of the post into a delta. The content must have been fetched, i.e. using Get.
+func (p *Post) ParseContent() (*delta.Delta, error) {
+	if p.Content == nil {
+		return nil, errors.New("slab: the content of the post has not been fetched")
+	}
+	return delta.Parse(*p.Content)
+}
+
 // List retrieves all the posts available in the organization including their details
 // but their content stays empty. Content is only available for filtered queries for now.
+// For large organizations, use Iterate to fetch them in smaller chunks.
 func (p *PostService) List() (*[]Post, error) {
+	return p.ListContext(context.Background())
+}
+
+// ListContext is like List but uses the given context for the request.
+func (p *PostService) ListContext(ctx context.Context) (*[]Post, error) {
 	query := `{
 		organization {
 			posts{
@@ -36,12 +53,20 @@ func (p *PostService) List() (*[]Post, error) {
 	var resp struct {
 		Organization *Organization `json:"organization"`
 	}
-	err := p.client.Do(context.Background(), query, nil, &resp)
-	return resp.Organization.Posts, err
+	err := p.client.Do(ctx, query, nil, &resp)
+	if resp.Organization != nil {
+		return resp.Organization.Posts, err
+	}
+	return nil, err
 }
 
 // Get retrieves the details of a specific post including its content
 func (p *PostService) Get(id string) (*Post, error) {
+	return p.GetContext(context.Background(), id)
+}
+
+// GetContext is like Get but uses the given context for the request.
+func (p *PostService) GetContext(ctx context.Context, id string) (*Post, error) {
 	query := `
 	query ($id: ID){
 		post(id: $id){
@@ -58,30 +83,87 @@ func (p *PostService) Get(id string) (*Post, error) {
 		Post *Post `json:"post"`
 	}
 	vars := map[string]interface{}{"id": id}
-	err := p.client.Do(context.Background(), query, vars, &resp)
+	err := p.client.Do(ctx, query, vars, &resp)
 	return resp.Post, err
-
 }
 
 // Create creates a new blank post, optionally organized in given topicId.
 func (p *PostService) Create(topicID string) (*Post, error) {
+	return p.CreateContext(context.Background(), topicID)
+}
+
+// CreateContext is like Create but uses the given context for the request.
+func (p *PostService) CreateContext(ctx context.Context, topicID string) (*Post, error) {
 	query := `mutation ($topicId: ID){ createPost(topicId: $topicId){ id } }`
 	var resp struct {
 		Post *Post `json:"createPost"`
 	}
 	vars := map[string]interface{}{"topicId": topicID}
-	err := p.client.Do(context.Background(), query, vars, &resp)
+	err := p.client.Do(ctx, query, vars, &resp)
 	return resp.Post, err
 }
 
-/*
-updatePost has been disabled for now by the slab team. They're rewriting it so disabling that for now.
+// UpdatePostOptions holds the changes to apply to a post with Update. Nil fields are left unchanged.
+type UpdatePostOptions struct {
+	// Content is the new content of the post, in the given Format.
+	Content *string
+	// Format is the format of Content, `MARKDOWN` or `HTML` as for Sync. It defaults to `MARKDOWN` when Content is
+	// set.
+	Format string
+	Title  *string
+	// Published publishes or unpublishes the post.
+	Published *bool
+	// Version, when set, is the version of the post the changes are based on. See Update for how the modifications
+	// made since that version are detected.
+	Version *int
+}
+
+// Update changes the content, title and/or publication state of a native (non-synced) post.
+//
+// The arguments sent to updatePost are not confirmed by slab's published schema: the mutation was disabled when
+// this client was written, and the previous version of it only took an id, a Json content and published. The content
+// and format follow syncPost and the title is assumed. Check them against your organization before relying on Update.
+//
+// The updatePost mutation takes no expected version, so opts.Version can not be enforced atomically by the API.
+// Instead, the post is first fetched and, when it is not at opts.Version anymore, Update fails with a
+// *VersionConflictError without sending the changes. As the post may still be modified between that check and the
+// update, the version returned by the update is checked too, assuming that an update increments it by at most one:
+// when it is above opts.Version+1, the updated post is returned along with a *VersionConflictError whose Applied field
+// is set, telling that the changes were applied on top of another modification they may have overwritten.
+func (p *PostService) Update(id string, opts UpdatePostOptions) (*Post, error) {
+	return p.UpdateContext(context.Background(), id, opts)
+}
+
+// UpdateContext is like Update but uses the given context for the requests.
+func (p *PostService) UpdateContext(ctx context.Context, id string, opts UpdatePostOptions) (*Post, error) {
+	if opts.Version != nil {
+		current, err := p.GetContext(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if current == nil {
+			return nil, ErrNotFound
+		}
+		if current.Version != *opts.Version {
+			return nil, &VersionConflictError{PostID: id, Expected: *opts.Version, Actual: current.Version}
+		}
+	}
 
-// Update changes the post to match the one given in parameter and/or its publication state.
-// The content parameter should be a field that matches
-func (p *PostService) Update(id, content string, published bool) (*Post, error) {
-	query := `mutation ($id: ID, $content: Json, $published: Boolean){
-		updatePost(id: $id, content: $content, published: $published){
+	query := `
+	mutation(
+		$id: ID!
+		$content: String
+		$format: PostContentFormat
+		$title: String
+		$published: Boolean
+	){
+		updatePost(
+			id: $id
+			content: $content
+			format: $format
+			title: $title
+			published: $published
+		){
 			id,
 			title,
 			version,
@@ -94,14 +176,36 @@ func (p *PostService) Update(id, content string, published bool) (*Post, error)
 	var resp struct {
 		Post *Post `json:"updatePost"`
 	}
-	vars := map[string]interface{}{"id": id, "content": content, "published": published}
-	err := p.client.Do(context.Background(), query, vars, &resp)
-	return resp.Post, err
+	vars := map[string]interface{}{"id": id}
+	if opts.Content != nil {
+		vars["content"] = *opts.Content
+		vars["format"] = opts.Format
+		if opts.Format == "" {
+			vars["format"] = "MARKDOWN"
+		}
+	}
+	if opts.Title != nil {
+		vars["title"] = *opts.Title
+	}
+	if opts.Published != nil {
+		vars["published"] = *opts.Published
+	}
+	if err := p.client.Do(ctx, query, vars, &resp); err != nil {
+		return resp.Post, err
+	}
+	if opts.Version != nil && resp.Post != nil && resp.Post.Version > *opts.Version+1 {
+		return resp.Post, &VersionConflictError{PostID: id, Expected: *opts.Version, Actual: resp.Post.Version, Applied: true}
+	}
+	return resp.Post, nil
 }
-*/
 
 // Delete deletes a post with given id or externalID. At least one must be supplied. If both are, id is used.
 func (p *PostService) Delete(id, externalID string) (*Post, error) {
+	return p.DeleteContext(context.Background(), id, externalID)
+}
+
+// DeleteContext is like Delete but uses the given context for the request.
+func (p *PostService) DeleteContext(ctx context.Context, id, externalID string) (*Post, error) {
 	query := `mutation($id: ID, $externalId: ID){ deletePost(id: $id, externalId: $externalId){ id } }`
 	var resp struct {
 		Post *Post `json:"deletePost"`
@@ -112,7 +216,7 @@ func (p *PostService) Delete(id, externalID string) (*Post, error) {
 	} else {
 		vars["externalId"] = externalID
 	}
-	err := p.client.Do(context.Background(), query, vars, &resp)
+	err := p.client.Do(ctx, query, vars, &resp)
 	return resp.Post, err
 }
 
@@ -126,6 +230,11 @@ func (p *PostService) Delete(id, externalID string) (*Post, error) {
 // * `editUR`L is the url you will be redirected to when you hit the "Edit Post" button in the slab UI.
 // * currently accepted `format` fields are `HTML` or `MARKDOWN`.
 func (p *PostService) Sync(externalID, content, editURL, readURL, format string) (*Post, error) {
+	return p.SyncContext(context.Background(), externalID, content, editURL, readURL, format)
+}
+
+// SyncContext is like Sync but uses the given context for the request.
+func (p *PostService) SyncContext(ctx context.Context, externalID, content, editURL, readURL, format string) (*Post, error) {
 	query := `
 	mutation(
 		$content: String!
@@ -160,7 +269,7 @@ func (p *PostService) Sync(externalID, content, editURL, readURL, format string)
 		"format":     format,
 		"readUrl":    readURL,
 	}
-	err := p.client.Do(context.Background(), query, vars, &resp)
+	err := p.client.Do(ctx, query, vars, &resp)
 	return resp.Post, err
 }
 
@@ -168,7 +277,12 @@ func (p *PostService) Sync(externalID, content, editURL, readURL, format string)
 //
 // Note that this is calling `Topic.AddToPost` and is only put here for convenience
 func (p *PostService) AddTopic(postID, topicID string) error {
-	_, err := p.client.Topic.AddToPost(topicID, postID)
+	return p.AddTopicContext(context.Background(), postID, topicID)
+}
+
+// AddTopicContext is like AddTopic but uses the given context for the request.
+func (p *PostService) AddTopicContext(ctx context.Context, postID, topicID string) error {
+	_, err := p.client.Topic.AddToPostContext(ctx, topicID, postID)
 	return err
 }
 
@@ -176,6 +290,11 @@ func (p *PostService) AddTopic(postID, topicID string) error {
 //
 // Note that this is calling `Topic.RemoveFromPost` and is only put here for convenience
 func (p *PostService) RemoveTopic(postID, topicID string) error {
-	_, err := p.client.Topic.RemoveFromPost(topicID, postID)
+	return p.RemoveTopicContext(context.Background(), postID, topicID)
+}
+
+// RemoveTopicContext is like RemoveTopic but uses the given context for the request.
+func (p *PostService) RemoveTopicContext(ctx context.Context, postID, topicID string) error {
+	_, err := p.client.Topic.RemoveFromPostContext(ctx, topicID, postID)
 	return err
 }
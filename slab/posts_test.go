@@ -1,11 +1,14 @@
 package slab
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPostService_List(t *testing.T) {
@@ -159,3 +162,109 @@ func TestRemoveTopic(t *testing.T) {
 		t.Errorf("Expecting no error, got: %v", err)
 	}
 }
+
+func TestPostService_Update(t *testing.T) {
+	want := &Post{ID: "abc123", Title: "New title", Version: 4}
+	c, calls, teardown := setup(t, `{"data":{"updatePost":{"id":"abc123","title":"New title","version":4}}}`)
+	defer teardown()
+
+	got, err := c.Post.Update("abc123", UpdatePostOptions{Content: String("# New title"), Title: String("New title"), Published: Bool(true)})
+	if err != nil {
+		t.Errorf("Expecting no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Update returned: %#v\nwant %#v", got, want)
+	}
+	if assert.Len(t, calls.requests(), 1) {
+		wantVars := map[string]interface{}{
+			"id": "abc123", "content": "# New title", "format": "MARKDOWN", "title": "New title", "published": true,
+		}
+		assert.Equal(t, wantVars, calls.requests()[0].Variables)
+	}
+}
+
+func TestPostService_Update_OnlyPublished(t *testing.T) {
+	c, calls, teardown := setup(t, `{"data":{"updatePost":{"id":"abc123","version":2}}}`)
+	defer teardown()
+
+	_, err := c.Post.Update("abc123", UpdatePostOptions{Published: Bool(false)})
+	assert.NoError(t, err)
+	if assert.Len(t, calls.requests(), 1) {
+		assert.Equal(t, map[string]interface{}{"id": "abc123", "published": false}, calls.requests()[0].Variables)
+	}
+}
+
+func TestPostService_Update_Version(t *testing.T) {
+	c, calls, teardown := setupServer(t, testServer{responses: []string{
+		`{"data":{"post":{"id":"abc123","version":3}}}`,
+		`{"data":{"updatePost":{"id":"abc123","version":4}}}`,
+	}})
+	defer teardown()
+
+	got, err := c.Post.Update("abc123", UpdatePostOptions{Content: String("<p>hi</p>"), Format: "HTML", Version: Int(3)})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, got.Version)
+	if assert.Len(t, calls.requests(), 2) {
+		assert.Equal(t, "HTML", calls.requests()[1].Variables["format"])
+	}
+}
+
+func TestPostService_Update_VersionNotIncremented(t *testing.T) {
+	// An update which does not bump the version is not taken for a conflict
+	c, _, teardown := setupServer(t, testServer{responses: []string{
+		`{"data":{"post":{"id":"abc123","version":3}}}`,
+		`{"data":{"updatePost":{"id":"abc123","version":3}}}`,
+	}})
+	defer teardown()
+
+	got, err := c.Post.Update("abc123", UpdatePostOptions{Published: Bool(true), Version: Int(3)})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, got.Version)
+}
+
+func TestPostService_Update_VersionConflict(t *testing.T) {
+	c, calls, teardown := setup(t, `{"data":{"post":{"id":"abc123","version":5}}}`)
+	defer teardown()
+
+	_, err := c.Post.Update("abc123", UpdatePostOptions{Title: String("New title"), Version: Int(3)})
+	assert.True(t, errors.Is(err, ErrVersionConflict))
+	var conflict *VersionConflictError
+	if assert.True(t, errors.As(err, &conflict)) {
+		assert.Equal(t, &VersionConflictError{PostID: "abc123", Expected: 3, Actual: 5}, conflict)
+	}
+	assert.Len(t, calls.requests(), 1, "the mutation must not be sent on conflict")
+}
+
+func TestPostService_Update_VersionConflictAfterCheck(t *testing.T) {
+	// The post is modified by someone else between the check and the update
+	c, calls, teardown := setupServer(t, testServer{responses: []string{
+		`{"data":{"post":{"id":"abc123","version":3}}}`,
+		`{"data":{"updatePost":{"id":"abc123","title":"New title","version":5}}}`,
+	}})
+	defer teardown()
+
+	got, err := c.Post.Update("abc123", UpdatePostOptions{Title: String("New title"), Version: Int(3)})
+	assert.True(t, errors.Is(err, ErrVersionConflict))
+	var conflict *VersionConflictError
+	if assert.True(t, errors.As(err, &conflict)) {
+		assert.Equal(t, &VersionConflictError{PostID: "abc123", Expected: 3, Actual: 5, Applied: true}, conflict)
+	}
+	assert.EqualError(t, err, "slab: post abc123 is at version 5 after an update based on version 3")
+	if assert.NotNil(t, got) {
+		assert.Equal(t, 5, got.Version)
+	}
+	assert.Len(t, calls.requests(), 2)
+}
+
+func TestPost_ParseContent(t *testing.T) {
+	content := `[{"insert":"slab-go"},{"attributes":{"header":1},"insert":"\n"}]`
+	p := &Post{ID: "abc123", Content: &content}
+	d, err := p.ParseContent()
+	if assert.NoError(t, err) {
+		assert.Len(t, d.Ops, 2)
+		assert.Equal(t, 1, d.Ops[1].Attributes.Header)
+	}
+
+	_, err = (&Post{ID: "abc123"}).ParseContent()
+	assert.Error(t, err)
+}
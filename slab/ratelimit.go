@@ -0,0 +1,113 @@
+package slab
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting the rate of the requests sent to the API. It is safe for concurrent use
+// and can be shared by several clients using WithRateLimiter.
+type RateLimiter struct {
+	// OnWait, when set, is called each time a request has to wait for the limiter with the time it is going to wait.
+	// This is handy to log throttling.
+	OnWait func(wait time.Duration)
+
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewRateLimiter returns a limiter allowing requestsPerSecond requests per second on average, with bursts of up to
+// burst requests. The bucket starts full. A burst lower than 1 is treated as 1.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// WithRateLimit makes the client send at most requestsPerSecond requests per second on average, with bursts of up
+// to burst requests. All the services of the client share the same limit.
+func WithRateLimit(requestsPerSecond float64, burst int) ClientOption {
+	return WithRateLimiter(NewRateLimiter(requestsPerSecond, burst))
+}
+
+// WithRateLimiter makes the client wait on the given limiter before each request.
+// Use it instead of WithRateLimit to share a limit between several clients.
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
+
+// RateLimiter returns the rate limiter used by the client, or nil if requests are not limited.
+func (c *Client) RateLimiter() *RateLimiter {
+	return c.limiter
+}
+
+// Delay returns how long a request sent now would have to wait for the limiter.
+func (l *RateLimiter) Delay() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	return l.delay()
+}
+
+// Wait blocks until a request can be sent or the context is done, in which case the context error is returned.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	l.refill()
+	wait := l.delay()
+	// The token is taken right away, possibly making the bucket negative, so that the following requests queue up
+	// behind this one.
+	l.tokens--
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	if l.OnWait != nil {
+		l.OnWait(wait)
+	}
+	if err := sleep(ctx, wait); err != nil {
+		// Give the token back as the request won't be sent
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// refill adds the tokens accumulated since the last call. l.mu must be held.
+func (l *RateLimiter) refill() {
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+// delay returns the time to wait for one token to be available. l.mu must be held.
+func (l *RateLimiter) delay() time.Duration {
+	if l.tokens >= 1 {
+		return 0
+	}
+	if l.rate <= 0 {
+		// No refill, the limiter only allows the initial burst. Wait "forever".
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
@@ -0,0 +1,108 @@
+package slab
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_Delay(t *testing.T) {
+	now := time.Date(2019, time.May, 1, 22, 44, 33, 0, time.UTC)
+	l := NewRateLimiter(2, 2)
+	l.now = func() time.Time { return now }
+
+	assert.Equal(t, time.Duration(0), l.Delay())
+	assert.NoError(t, l.Wait(context.Background()))
+	assert.NoError(t, l.Wait(context.Background()))
+	// Bucket is empty, one token comes every 500ms
+	assert.Equal(t, 500*time.Millisecond, l.Delay())
+
+	now = now.Add(250 * time.Millisecond)
+	assert.Equal(t, 250*time.Millisecond, l.Delay())
+
+	// Refill never goes beyond the burst
+	now = now.Add(time.Hour)
+	assert.Equal(t, time.Duration(0), l.Delay())
+	assert.Equal(t, 2.0, l.tokens)
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	var waits []time.Duration
+	l := NewRateLimiter(50, 1)
+	l.OnWait = func(wait time.Duration) { waits = append(waits, wait) }
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, l.Wait(context.Background()))
+	}
+	// First request goes through right away, the 3 others wait 20ms each
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("4 requests at 50 rps took %v, expecting at least 60ms", elapsed)
+	}
+	assert.Len(t, waits, 3)
+}
+
+func TestRateLimiter_WaitCancelled(t *testing.T) {
+	l := NewRateLimiter(0.001, 1)
+	assert.NoError(t, l.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := l.Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expecting context.DeadlineExceeded, got: %v", err)
+	}
+	// The token reserved by the cancelled call must have been given back
+	assert.InDelta(t, 0, l.tokens, 0.01)
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	c, calls, teardown := setupServer(t, testServer{
+		responses: []string{`{"data":{"post":{"id":"abc123"}}}`},
+		opts:      []ClientOption{WithRateLimit(40, 2)},
+	})
+	defer teardown()
+	if assert.NotNil(t, c.RateLimiter()) {
+		assert.Equal(t, time.Duration(0), c.RateLimiter().Delay())
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Post.Get("abc123")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	// 2 requests from the burst, then 2 more at 25ms interval
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Errorf("4 requests at 40 rps with a burst of 2 took %v, expecting at least 50ms", elapsed)
+	}
+	assert.Equal(t, 4, calls.count())
+}
+
+func TestClient_RateLimitCancelled(t *testing.T) {
+	l := NewRateLimiter(0.001, 1)
+	c, calls, teardown := setupServer(t, testServer{
+		responses: []string{`{"data":{"post":{"id":"abc123"}}}`},
+		opts:      []ClientOption{WithRateLimiter(l)},
+	})
+	defer teardown()
+
+	_, err := c.Post.Get("abc123")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = c.Post.GetContext(ctx, "abc123")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 1, calls.count())
+	assert.Nil(t, NewClient(nil, "dummy_token").RateLimiter())
+}
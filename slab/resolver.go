@@ -0,0 +1,146 @@
+package slab
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// TopicResolver resolves paths of topic names to topic ids like AutoGenerate, creating the missing topics, but
+// lists the topics only once and keeps them in memory. It is safe for concurrent use: the resolutions of paths
+// sharing missing topics wait for each other so that every topic is created only once.
+//
+// The topics created or modified by other means are not seen until Refresh is called.
+type TopicResolver struct {
+	service *TopicService
+
+	mu       sync.Mutex
+	tree     *TopicTree
+	loading  *resolverCall
+	inflight map[string]*resolverCall // creations in progress by parent id and folded name
+}
+
+// resolverCall is an operation in progress that others can wait for.
+type resolverCall struct {
+	done chan struct{}
+	id   string
+	err  error
+}
+
+// wait returns the result of the call once done, or the error of the context if it is canceled first.
+func (c *resolverCall) wait(ctx context.Context) (string, error) {
+	select {
+	case <-c.done:
+		return c.id, c.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// NewResolver returns a TopicResolver creating the missing topics with this service.
+func (t *TopicService) NewResolver() *TopicResolver {
+	return &TopicResolver{service: t, inflight: make(map[string]*resolverCall)}
+}
+
+// Resolve returns the id of the leaf topic of the given `topicHierarchy` split using `separator`, creating the missing
+// topics, just like TopicService.AutoGenerate does. The topics are listed on the first call only.
+func (r *TopicResolver) Resolve(ctx context.Context, topicHierarchy, separator string) (topicID string, err error) {
+	names, err := ParseTopicPath(topicHierarchy, separator)
+	if err != nil {
+		return "", err
+	}
+	if err := r.load(ctx); err != nil {
+		return "", err
+	}
+	for _, name := range names {
+		if topicID, err = r.resolveChild(ctx, topicID, name); err != nil {
+			return "", err
+		}
+	}
+	return topicID, nil
+}
+
+// Refresh lists the topics again, to see the changes made by other means than the resolver.
+func (r *TopicResolver) Refresh(ctx context.Context) error {
+	topics, err := r.service.ListContext(ctx)
+	if err != nil {
+		return err
+	}
+	var list []Topic
+	if topics != nil {
+		list = *topics
+	}
+	tree, err := NewTopicTree(list)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.tree = tree
+	r.mu.Unlock()
+	return nil
+}
+
+// load lists the topics unless it was already done. Concurrent calls share the same listing.
+func (r *TopicResolver) load(ctx context.Context) error {
+	r.mu.Lock()
+	if r.tree != nil {
+		r.mu.Unlock()
+		return nil
+	}
+	c := r.loading
+	if c == nil {
+		c = &resolverCall{done: make(chan struct{})}
+		r.loading = c
+		go func() {
+			// The listing is shared so it must not be canceled with the context of the first caller
+			c.err = r.Refresh(context.Background())
+			r.mu.Lock()
+			r.loading = nil
+			r.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	r.mu.Unlock()
+	_, err := c.wait(ctx)
+	return err
+}
+
+// resolveChild returns the id of the child of the given name of the parent topic, creating it if it does not exist.
+func (r *TopicResolver) resolveChild(ctx context.Context, parentID, name string) (string, error) {
+	r.mu.Lock()
+	if child := r.tree.child(parentID, name); child != nil {
+		r.mu.Unlock()
+		return child.ID, nil
+	}
+	key := parentID + "/" + FoldTopicName(name)
+	c, ok := r.inflight[key]
+	if !ok {
+		c = &resolverCall{done: make(chan struct{})}
+		r.inflight[key] = c
+		go r.create(c, key, parentID, name)
+	}
+	r.mu.Unlock()
+	return c.wait(ctx)
+}
+
+// create creates a topic and adds it to the tree.
+func (r *TopicResolver) create(c *resolverCall, key, parentID, name string) {
+	// The creation is shared so it must not be canceled with the context of the first caller
+	created, err := r.service.CreateContext(context.Background(), name, "", parentID)
+	if err == nil && created == nil {
+		err = errors.New("slab: the created topic was not returned")
+	}
+	r.mu.Lock()
+	if err == nil {
+		c.id = created.ID
+		topic := Topic{ID: created.ID, Name: name, Description: created.Description}
+		if parentID != "" {
+			topic.Parent = &Topic{ID: parentID}
+		}
+		r.tree.add(topic)
+	}
+	c.err = err
+	delete(r.inflight, key)
+	r.mu.Unlock()
+	close(c.done)
+}
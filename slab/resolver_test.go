@@ -0,0 +1,97 @@
+package slab_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/VEVO/slab-go/internal/slabtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicResolver_Resolve(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	eng := srv.AddTopic("Engineering", "")
+	services := srv.AddTopic("Services", eng)
+
+	r := srv.Client().Topic.NewResolver()
+	ctx := context.Background()
+
+	id, err := r.Resolve(ctx, "engineering/SERVICES", "/")
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, services, id)
+
+	id, err = r.Resolve(ctx, "Engineering/Services/App 1", "/")
+	assert.NoError(t, err)
+	assert.Equal(t, "Engineering/Services/App 1", srv.TopicPath(id))
+
+	// The created topic is cached
+	again, err := r.Resolve(ctx, "Engineering/Services/app 1", "/")
+	assert.NoError(t, err)
+	assert.Equal(t, id, again)
+	assert.Equal(t, 1, srv.Calls["organization"])
+	assert.Equal(t, 1, srv.Calls["createTopic"])
+}
+
+func TestTopicResolver_Concurrent(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	srv.AddTopic("Engineering", "")
+
+	r := srv.Client().Topic.NewResolver()
+	var wg sync.WaitGroup
+	ids := make([]string, 40)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// The paths share their missing "Services" topic
+			id, err := r.Resolve(context.Background(), fmt.Sprintf("Engineering/Services/App %d", i%4), "/")
+			assert.NoError(t, err)
+			ids[i] = id
+		}(i)
+	}
+	wg.Wait()
+
+	// Engineering, Services and the 4 apps
+	assert.Len(t, srv.Topics, 6)
+	assert.Equal(t, 5, srv.Calls["createTopic"])
+	assert.Equal(t, 1, srv.Calls["organization"])
+	for i, id := range ids {
+		assert.Equal(t, fmt.Sprintf("Engineering/Services/App %d", i%4), srv.TopicPath(id))
+	}
+}
+
+func TestTopicResolver_Refresh(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	r := srv.Client().Topic.NewResolver()
+	ctx := context.Background()
+	_, err := r.Resolve(ctx, "Engineering", "/")
+	assert.NoError(t, err)
+
+	// Created by someone else
+	other := srv.AddTopic("Marketing", "")
+
+	assert.NoError(t, r.Refresh(ctx))
+	id, err := r.Resolve(ctx, "marketing", "/")
+	assert.NoError(t, err)
+	assert.Equal(t, other, id)
+	assert.Equal(t, 2, srv.Calls["organization"])
+	assert.Equal(t, 1, srv.Calls["createTopic"])
+}
+
+func TestTopicResolver_Canceled(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := srv.Client().Topic.NewResolver().Resolve(ctx, "Engineering", "/")
+	assert.Equal(t, context.Canceled, err)
+}
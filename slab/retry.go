@@ -0,0 +1,142 @@
+package slab
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryPolicy describes how the client retries requests that failed for transient reasons: network errors,
+// 5xx HTTP statuses and rate limiting.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. Values lower than 2 disable retries.
+	MaxAttempts int
+	// MinBackoff is the wait before the first retry. It doubles on each following retry.
+	MinBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts. It does not apply to waits requested by the API through the
+	// Retry-After header.
+	MaxBackoff time.Duration
+	// RetryMutations allows retrying mutations. As they may not be idempotent, they are never retried by default.
+	RetryMutations bool
+	// OnRetry, when set, is called before waiting for each retry with the number of the attempt that failed, its
+	// error and the time the client is going to wait before the next attempt.
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+// DefaultRetryPolicy returns a policy that retries queries up to 4 times in total, waiting between 0.5s and 30s
+// between attempts.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		MinBackoff:  500 * time.Millisecond,
+		MaxBackoff:  30 * time.Second,
+	}
+}
+
+// WithRetryPolicy makes the client retry requests failing with transient errors according to the given policy.
+// Without this option, requests are never retried.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = &policy
+	}
+}
+
+// jitter is a source of random numbers, seeded on creation, that is safe for concurrent use.
+type jitter struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newJitter() *jitter {
+	return &jitter{rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// int63n returns a random number in [0,n).
+func (j *jitter) int63n(n int64) int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.rnd.Int63n(n)
+}
+
+// canRetry tells whether the query is allowed to be retried at all by the policy.
+func (p *RetryPolicy) canRetry(query string) bool {
+	return p != nil && p.MaxAttempts > 1 && (p.RetryMutations || !isMutation(query))
+}
+
+// backoff returns the time to wait after the given failed attempt (starting at 1), randomized using j.
+func (p *RetryPolicy) backoff(attempt int, err error, j *jitter) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	// Equal jitter: keep half of the backoff and randomize the other half to avoid all the clients retrying at
+	// the same time.
+	if half := int64(d / 2); half > 0 {
+		d = time.Duration(half + j.int63n(half))
+	}
+	if ra := retryAfter(err); ra > d {
+		d = ra
+	}
+	return d
+}
+
+// isRetryable tells whether err is a transient failure worth retrying.
+func isRetryable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	if IsRateLimited(err) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// retryAfter returns the wait requested by the API through the Retry-After header, if any.
+func retryAfter(err error) time.Duration {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Header == nil {
+		return 0
+	}
+	v := httpErr.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// isMutation tells whether the given graphql document is a mutation.
+func isMutation(query string) bool {
+	return strings.HasPrefix(strings.TrimSpace(query), "mutation")
+}
+
+// sleep waits for d or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,173 @@
+package slab
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+}
+
+func TestRetry_TransientFailures(t *testing.T) {
+	var retries []int
+	policy := testRetryPolicy()
+	policy.OnRetry = func(attempt int, err error, wait time.Duration) {
+		assert.Error(t, err)
+		retries = append(retries, attempt)
+	}
+	c, calls, teardown := setupServer(t, testServer{
+		failures:  []int{http.StatusBadGateway, 0},
+		responses: []string{`{"data":{"post":{"id":"abc123"}}}`},
+		opts:      []ClientOption{WithRetryPolicy(policy)},
+	})
+	defer teardown()
+
+	got, err := c.Post.Get("abc123")
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, "abc123", got.ID)
+	assert.Equal(t, 3, calls.count())
+	assert.Equal(t, []int{1, 2}, retries)
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	c, calls, teardown := setupServer(t, testServer{
+		failures:  []int{500, 503, 502, 500},
+		responses: []string{`{"data":{"post":{"id":"abc123"}}}`},
+		opts:      []ClientOption{WithRetryPolicy(testRetryPolicy())},
+	})
+	defer teardown()
+
+	_, err := c.Post.Get("abc123")
+	var httpErr *HTTPError
+	if assert.True(t, errors.As(err, &httpErr)) {
+		assert.Equal(t, http.StatusBadGateway, httpErr.StatusCode)
+	}
+	assert.Equal(t, 3, calls.count())
+}
+
+func TestRetry_NotRetryable(t *testing.T) {
+	c, calls, teardown := setupServer(t, testServer{
+		failures:  []int{http.StatusBadRequest},
+		responses: []string{`{"data":{"post":{"id":"abc123"}}}`},
+		opts:      []ClientOption{WithRetryPolicy(testRetryPolicy())},
+	})
+	defer teardown()
+
+	_, err := c.Post.Get("abc123")
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls.count())
+}
+
+func TestRetry_Mutations(t *testing.T) {
+	c, calls, teardown := setupServer(t, testServer{
+		failures:  []int{http.StatusServiceUnavailable},
+		responses: []string{`{"data":{"createPost":{"id":"abc123"}}}`},
+		opts:      []ClientOption{WithRetryPolicy(testRetryPolicy())},
+	})
+	defer teardown()
+
+	_, err := c.Post.Create("")
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls.count(), "mutations must not be retried by default")
+
+	policy := testRetryPolicy()
+	policy.RetryMutations = true
+	c, calls, teardown = setupServer(t, testServer{
+		failures:  []int{http.StatusServiceUnavailable},
+		responses: []string{`{"data":{"createPost":{"id":"abc123"}}}`},
+		opts:      []ClientOption{WithRetryPolicy(policy)},
+	})
+	defer teardown()
+
+	got, err := c.Post.Create("")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", got.ID)
+	assert.Equal(t, 2, calls.count())
+}
+
+func TestRetry_NoPolicy(t *testing.T) {
+	c, calls, teardown := setupServer(t, testServer{
+		failures:  []int{http.StatusBadGateway},
+		responses: []string{`{"data":{"post":{"id":"abc123"}}}`},
+	})
+	defer teardown()
+
+	_, err := c.Post.Get("abc123")
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls.count())
+}
+
+func TestRetry_ContextCancelledWhileWaiting(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Hour, MaxBackoff: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	policy.OnRetry = func(int, error, time.Duration) { cancel() }
+	c, calls, teardown := setupServer(t, testServer{
+		failures:  []int{http.StatusBadGateway},
+		responses: []string{`{"data":{"post":{"id":"abc123"}}}`},
+		opts:      []ClientOption{WithRetryPolicy(policy)},
+	})
+	defer teardown()
+
+	_, err := c.Post.GetContext(ctx, "abc123")
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls.count())
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	j := newJitter()
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 200 * time.Millisecond, 400 * time.Millisecond},
+		{10, 500 * time.Millisecond, time.Second},
+	}
+	for _, tt := range tests {
+		got := p.backoff(tt.attempt, nil, j)
+		if got < tt.min || got > tt.max {
+			t.Errorf("backoff(%d) returned %v, want between %v and %v", tt.attempt, got, tt.min, tt.max)
+		}
+	}
+
+	err := &HTTPError{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"7"}}}
+	assert.Equal(t, 7*time.Second, p.backoff(1, err, j))
+}
+
+func TestRetryAfter(t *testing.T) {
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		header   string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"12", 12 * time.Second, 12 * time.Second},
+		{date, 58 * time.Second, time.Minute},
+		{"garbage", 0, 0},
+	}
+	for _, tt := range tests {
+		err := &HTTPError{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+		err.Header.Set("Retry-After", tt.header)
+		got := retryAfter(err)
+		if got < tt.min || got > tt.max {
+			t.Errorf("retryAfter(%q) returned %v, want between %v and %v", tt.header, got, tt.min, tt.max)
+		}
+	}
+	assert.Equal(t, time.Duration(0), retryAfter(errors.New("not an http error")))
+}
+
+func TestIsMutation(t *testing.T) {
+	assert.True(t, isMutation("\n\tmutation ($id: ID){ deletePost(id: $id){ id } }"))
+	assert.False(t, isMutation("{ organization { id } }"))
+	assert.False(t, isMutation("query ($id: ID){ post(id: $id){ id } }"))
+}
@@ -1,7 +1,10 @@
 package slab
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,17 +12,25 @@ import (
 	"github.com/machinebox/graphql"
 )
 
-// apiEndpoint is a var and not a constant to permit easier testing.
-// But we should be able to have that fixed if upstream accepts
-// https://github.com/machinebox/graphql/pull/38
-var apiEndpoint = "https://api.slab.com/v1/graphql"
+// DefaultEndpoint is the slab graphql API endpoint used when no WithEndpoint option is given to NewClient.
+const DefaultEndpoint = "https://api.slab.com/v1/graphql"
 
 // Client is the client used for the graphql api
 type Client struct {
 	client *graphql.Client
-	// APIToken is the authentication token to use when talking to the slab API
+	// APIToken is the authentication token to use when talking to the slab API, unless a TokenSource is given with
+	// WithTokenSource.
 	APIToken string
 
+	endpoint  string
+	userAgent string
+	headers   http.Header
+	logger    func(s string)
+	retry     *RetryPolicy
+	jitter    *jitter
+	limiter   *RateLimiter
+	tokens    TokenSource
+
 	common       service
 	Organization *OrganizationService
 	Post         *PostService
@@ -27,15 +38,63 @@ type Client struct {
 	User         *UserService
 }
 
+// ClientOption is a function that configures a Client when passed to NewClient.
+type ClientOption func(*Client)
+
+// WithEndpoint makes the client talk to the given graphql endpoint instead of DefaultEndpoint.
+// This is handy when going through a proxy or for testing.
+func WithEndpoint(endpoint string) ClientOption {
+	return func(c *Client) {
+		c.endpoint = endpoint
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithLogger sets a function that receives the debug output of the client (queries, variables, headers and
+// raw responses). For example, to log to the standard logger:
+//
+//	slab.NewClient(httpClient, token, slab.WithLogger(func(s string) { log.Println(s) }))
+//
+// Beware that the headers logged include the API token.
+func WithLogger(logger func(s string)) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithHeader adds a header that is sent with every request. It can be given multiple times, including with the
+// same key. The Authorization header is always set from the API token and cannot be overridden that way.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		c.headers.Add(key, value)
+	}
+}
+
 // NewClient creates a new slab client with the provided http.Client.
 // If httpClient is nil, then http.DefaultClient is used.
-func NewClient(httpClient *http.Client, apiToken string) *Client {
+// The client can be further configured using the ClientOption functions provided.
+func NewClient(httpClient *http.Client, apiToken string, opts ...ClientOption) *Client {
 	c := &Client{
-		client:   graphql.NewClient(apiEndpoint, graphql.WithHTTPClient(httpClient)),
 		APIToken: apiToken,
+		endpoint: DefaultEndpoint,
+		headers:  make(http.Header),
+		jitter:   newJitter(),
 	}
-	// For debugging
-	// c.client.Log = func(s string) { log.Println(s) }
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.client = graphql.NewClient(c.endpoint, graphql.WithHTTPClient(recordingClient(httpClient)))
+	if c.logger != nil {
+		c.client.Log = c.logger
+	}
+
 	c.common.client = c
 	c.Organization = (*OrganizationService)(&c.common)
 	c.Post = (*PostService)(&c.common)
@@ -51,20 +110,146 @@ type service struct {
 
 // Do executes the given query and populates the resp struct.
 // `graphqlVars` is a map of the graphql variables to pass to the query.
+//
+// When the API answers with graphql errors, the returned error is of type Errors. When it answers with a
+// non-successful HTTP status, the error is an *HTTPError. Both can be inspected using errors.As or the IsNotFound,
+// IsUnauthorized and IsRateLimited helpers. When the TokenSource of the client fails, the error is a *TokenError.
+//
+// Transient failures are retried according to the RetryPolicy given with WithRetryPolicy if any.
 func (c *Client) Do(ctx context.Context, query string, graphqlVars map[string]interface{}, resp interface{}) error {
+	if !c.retry.canRetry(query) {
+		return c.do(ctx, query, graphqlVars, resp)
+	}
+	for attempt := 1; ; attempt++ {
+		err := c.do(ctx, query, graphqlVars, resp)
+		if err == nil || attempt >= c.retry.MaxAttempts || !isRetryable(err) {
+			return err
+		}
+		wait := c.retry.backoff(attempt, err, c.jitter)
+		if c.retry.OnRetry != nil {
+			c.retry.OnRetry(attempt, err, wait)
+		}
+		if sleepErr := sleep(ctx, wait); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// do executes a single attempt of the query, waiting for the rate limiter first if any.
+func (c *Client) do(ctx context.Context, query string, graphqlVars map[string]interface{}, resp interface{}) error {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	token, err := c.token(ctx)
+	if err != nil {
+		return err
+	}
+
 	req := graphql.NewRequest(query)
-	req.Header.Set("Authorization", c.APIToken)
+	for k, values := range c.headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	req.Header.Set("Authorization", token)
 
 	for k, v := range graphqlVars {
 		req.Var(k, v)
 	}
 
-	if err := c.client.Run(ctx, req, resp); err != nil {
-		return err
+	rec := &recordedResponse{}
+	err = rec.err(c.client.Run(context.WithValue(ctx, recordedResponseKey{}, rec), req, resp))
+	if IsUnauthorized(err) {
+		c.invalidateToken()
+	}
+	return err
+}
+
+// recordedResponseKey is the context key under which Do stores the recordedResponse for the recorder to fill.
+type recordedResponseKey struct{}
+
+// recordedResponse keeps what the graphql library does not expose about the last HTTP response received.
+type recordedResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// err turns the error returned by the graphql library into a typed error using the recorded response.
+// runErr is returned as is when nothing better can be said, for example on network errors.
+func (r *recordedResponse) err(runErr error) error {
+	if r.body == nil {
+		return runErr
+	}
+	var body struct {
+		Errors Errors `json:"errors"`
+	}
+	// The body may not be json at all, for example on proxy errors, in which case we just don't get the errors.
+	_ = json.Unmarshal(r.body, &body)
+
+	if r.statusCode < 200 || r.statusCode > 299 {
+		return &HTTPError{StatusCode: r.statusCode, Header: r.header, Body: r.body, Errors: body.Errors}
+	}
+	if len(body.Errors) > 0 {
+		return body.Errors
+	}
+	return runErr
+}
+
+// recordingClient returns a copy of httpClient whose transport records the responses in the recordedResponse
+// found in the request context.
+func recordingClient(httpClient *http.Client) *http.Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
 	}
-	return nil
+	hc := *httpClient
+	hc.Transport = &recorder{next: httpClient.Transport}
+	return &hc
+}
+
+// recorder is an http.RoundTripper buffering the response body so that it can be read both by the graphql
+// library and by Do.
+type recorder struct {
+	next http.RoundTripper
 }
 
+func (rt *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := rt.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	res, err := next.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+	rec, ok := req.Context().Value(recordedResponseKey{}).(*recordedResponse)
+	if !ok {
+		return res, nil
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	rec.statusCode, rec.header, rec.body = res.StatusCode, res.Header, body
+	return res, nil
+}
+
+// String returns a pointer to the given string. It is handy to fill optional fields.
+func String(v string) *string { return &v }
+
+// Bool returns a pointer to the given bool. It is handy to fill optional fields.
+func Bool(v bool) *bool { return &v }
+
+// Int returns a pointer to the given int. It is handy to fill optional fields.
+func Int(v int) *int { return &v }
+
 // DateTime is a struct that allow us to unmarshal the RFC3339 date formats
 type DateTime struct {
 	time.Time
@@ -1,11 +1,16 @@
 package slab
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -16,23 +21,247 @@ func testMethod(t *testing.T, r *http.Request, want string) {
 	}
 }
 
-func setup(t *testing.T, response string) (c *Client, mux *http.ServeMux, teardown func()) {
-	// mux is the test server router
-	mux = http.NewServeMux()
+// testServer configures the server answering the client returned by setupServer.
+type testServer struct {
+	// responses are the bodies answered to the requests in turn. A single response is answered to every request.
+	// Any request beyond them is reported as unexpected, unless handler is set.
+	responses []string
+	// status is the HTTP status of the responses, http.StatusOK by default.
+	status int
+	// failures are the HTTP statuses answered without a body to the first requests, before the responses. A 0
+	// status resets the connection instead.
+	failures []int
+	// handler, when set, returns the value answered as JSON to each request instead of the responses.
+	handler func(req graphqlRequest) interface{}
+	// block makes the server hold each request until it is aborted by the client.
+	block bool
+	// opts are the options given to the client, after the endpoint of the server.
+	opts []ClientOption
+}
+
+// graphqlRequest is the body of a request received by the test servers.
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// serverCalls records the requests received by a server started with setupServer.
+type serverCalls struct {
+	mu       sync.Mutex
+	received []graphqlRequest
+	// started receives a value each time a request reaches a blocking server.
+	started chan struct{}
+}
+
+// requests returns the requests received so far, in order.
+func (s *serverCalls) requests() []graphqlRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]graphqlRequest(nil), s.received...)
+}
+
+// count returns the number of requests received so far.
+func (s *serverCalls) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.received)
+}
+
+func setup(t *testing.T, response string) (c *Client, calls *serverCalls, teardown func()) {
+	return setupServer(t, testServer{responses: []string{response}})
+}
 
+// setupServer returns a client talking to a test server configured by srv, and the requests that server receives.
+func setupServer(t *testing.T, srv testServer) (c *Client, calls *serverCalls, teardown func()) {
+	calls = &serverCalls{started: make(chan struct{}, 1)}
+	mux := http.NewServeMux()
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		testMethod(t, r, "POST")
-		_, err := ioutil.ReadAll(r.Body)
+		// The body is read entirely for the server to notice when the client aborts the request
+		body, err := ioutil.ReadAll(r.Body)
 		assert.NoError(t, err)
-		_, err = io.WriteString(w, response)
+		var req graphqlRequest
+		assert.NoError(t, json.Unmarshal(body, &req))
+		calls.mu.Lock()
+		calls.received = append(calls.received, req)
+		n := len(calls.received)
+		calls.mu.Unlock()
+
+		switch {
+		case srv.block:
+			calls.started <- struct{}{}
+			<-r.Context().Done()
+			return
+		case n <= len(srv.failures):
+			if srv.failures[n-1] == 0 {
+				// Simulate a connection reset
+				conn, _, err := w.(http.Hijacker).Hijack()
+				assert.NoError(t, err)
+				conn.Close()
+				return
+			}
+			w.WriteHeader(srv.failures[n-1])
+			return
+		case srv.handler != nil:
+			assert.NoError(t, json.NewEncoder(w).Encode(srv.handler(req)))
+			return
+		}
+
+		i := n - len(srv.failures) - 1
+		if len(srv.responses) == 1 {
+			i = 0
+		}
+		if i >= len(srv.responses) {
+			t.Errorf("Unexpected request #%d: %s", n, req.Query)
+			return
+		}
+		if srv.status != 0 {
+			w.WriteHeader(srv.status)
+		}
+		_, err = io.WriteString(w, srv.responses[i])
 		assert.NoError(t, err)
 	})
 
-	// srv is the test server that will serve the endpoints
+	// ts is the test server that will serve the endpoints
+	ts := httptest.NewServer(mux)
+	c = NewClient(&http.Client{}, "dummy_token", append([]ClientOption{WithEndpoint(ts.URL)}, srv.opts...)...)
+
+	return c, calls, ts.Close
+}
+
+func TestContextCancellation(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(ctx context.Context, c *Client) error
+	}{
+		{"Organization.GetContext", func(ctx context.Context, c *Client) error { _, err := c.Organization.GetContext(ctx); return err }},
+		{"Post.ListContext", func(ctx context.Context, c *Client) error { _, err := c.Post.ListContext(ctx); return err }},
+		{"Post.GetContext", func(ctx context.Context, c *Client) error { _, err := c.Post.GetContext(ctx, "abc123"); return err }},
+		{"Post.CreateContext", func(ctx context.Context, c *Client) error { _, err := c.Post.CreateContext(ctx, ""); return err }},
+		{"Post.DeleteContext", func(ctx context.Context, c *Client) error {
+			_, err := c.Post.DeleteContext(ctx, "abc123", "")
+			return err
+		}},
+		{"Post.SyncContext", func(ctx context.Context, c *Client) error {
+			_, err := c.Post.SyncContext(ctx, "ext", "# title", "https://example.com", "", "MARKDOWN")
+			return err
+		}},
+		{"Post.AddTopicContext", func(ctx context.Context, c *Client) error { return c.Post.AddTopicContext(ctx, "post", "topic") }},
+		{"Post.RemoveTopicContext", func(ctx context.Context, c *Client) error { return c.Post.RemoveTopicContext(ctx, "post", "topic") }},
+		{"Topic.ListContext", func(ctx context.Context, c *Client) error { _, err := c.Topic.ListContext(ctx); return err }},
+		{"Topic.ListWithPostsContext", func(ctx context.Context, c *Client) error { _, err := c.Topic.ListWithPostsContext(ctx); return err }},
+		{"Topic.GetContext", func(ctx context.Context, c *Client) error { _, err := c.Topic.GetContext(ctx, "abc123"); return err }},
+		{"Topic.CreateContext", func(ctx context.Context, c *Client) error {
+			_, err := c.Topic.CreateContext(ctx, "foo", "", "")
+			return err
+		}},
+		{"Topic.AddToPostContext", func(ctx context.Context, c *Client) error {
+			_, err := c.Topic.AddToPostContext(ctx, "topic", "post")
+			return err
+		}},
+		{"Topic.RemoveFromPostContext", func(ctx context.Context, c *Client) error {
+			_, err := c.Topic.RemoveFromPostContext(ctx, "topic", "post")
+			return err
+		}},
+		{"Topic.AutoGenerateContext", func(ctx context.Context, c *Client) error {
+			_, err := c.Topic.AutoGenerateContext(ctx, "Engineering/Services", "/")
+			return err
+		}},
+		{"User.ListContext", func(ctx context.Context, c *Client) error { _, err := c.User.ListContext(ctx); return err }},
+		{"User.GetContext", func(ctx context.Context, c *Client) error { _, err := c.User.GetContext(ctx, "abc123"); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, calls, teardown := setupServer(t, testServer{block: true})
+			defer teardown()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				<-calls.started
+				cancel()
+			}()
+
+			done := make(chan error, 1)
+			go func() { done <- tt.call(ctx, c) }()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("Expecting context.Canceled, got: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("request was not aborted by the context cancellation")
+			}
+		})
+	}
+}
+
+func TestContextCancellation_BeforeRequest(t *testing.T) {
+	c, calls, teardown := setupServer(t, testServer{block: true})
+	defer teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.Post.ListContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expecting context.Canceled, got: %v", err)
+	}
+	select {
+	case <-calls.started:
+		t.Error("Expecting no request to reach the server")
+	default:
+	}
+}
+
+func TestNewClient_Defaults(t *testing.T) {
+	c := NewClient(nil, "dummy_token")
+	assert.Equal(t, DefaultEndpoint, c.endpoint)
+	assert.Equal(t, "dummy_token", c.APIToken)
+	assert.Empty(t, c.userAgent)
+	assert.Empty(t, c.headers)
+}
+
+func TestNewClient_Options(t *testing.T) {
+	var gotHeader http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		gotHeader = r.Header
+		_, err := io.WriteString(w, `{"data":{"post":{"id":"abc123"}}}`)
+		assert.NoError(t, err)
+	})
 	srv := httptest.NewServer(mux)
-	apiEndpoint = srv.URL
+	defer srv.Close()
+
+	var logs []string
+	c := NewClient(&http.Client{}, "dummy_token",
+		WithEndpoint(srv.URL),
+		WithUserAgent("slab-go-test/1.0"),
+		WithLogger(func(s string) { logs = append(logs, s) }),
+		WithHeader("X-Trace", "one"),
+		WithHeader("X-Trace", "two"),
+		WithHeader("Authorization", "overridden"),
+	)
+
+	_, err := c.Post.Get("abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "slab-go-test/1.0", gotHeader.Get("User-Agent"))
+	assert.Equal(t, []string{"one", "two"}, gotHeader["X-Trace"])
+	assert.Equal(t, "dummy_token", gotHeader.Get("Authorization"))
+	assert.NotEmpty(t, logs)
+}
 
-	c = NewClient(&http.Client{}, "dummy_token")
+func TestNewClient_SeparateEndpoints(t *testing.T) {
+	c1, _, teardown1 := setup(t, `{"data":{"post":{"id":"fromServer1"}}}`)
+	defer teardown1()
+	c2, _, teardown2 := setup(t, `{"data":{"post":{"id":"fromServer2"}}}`)
+	defer teardown2()
 
-	return c, mux, srv.Close
+	p1, err := c1.Post.Get("abc123")
+	assert.NoError(t, err)
+	p2, err := c2.Post.Get("abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "fromServer1", p1.ID)
+	assert.Equal(t, "fromServer2", p2.ID)
 }
@@ -0,0 +1,226 @@
+package slab
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrToken is matched by the errors returned when the API token can not be obtained from its TokenSource.
+var ErrToken = errors.New("slab: can not get the API token")
+
+// TokenError is returned by Client.Do when its TokenSource fails. No request is sent in that case.
+type TokenError struct {
+	// Source describes the token source, i.e. `file "/var/run/secrets/slab/token"`.
+	Source string
+	Err    error
+}
+
+func (e *TokenError) Error() string {
+	return fmt.Sprintf("slab: can not get the API token from %s: %v", e.Source, e.Err)
+}
+
+func (e *TokenError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrToken) work.
+func (e *TokenError) Is(target error) bool {
+	return target == ErrToken
+}
+
+// TokenSource provides the API token of the requests. It is consulted by the client before every request, so that a
+// token rotated by a secret manager is picked up without recreating the client. Token must be safe for concurrent
+// use.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// WithTokenSource makes the client get the API token from the given source for every request, instead of using the
+// token given to NewClient.
+func WithTokenSource(src TokenSource) ClientOption {
+	return func(c *Client) {
+		c.tokens = src
+	}
+}
+
+// token returns the API token to send with the next request.
+func (c *Client) token(ctx context.Context) (string, error) {
+	if c.tokens == nil {
+		return c.APIToken, nil
+	}
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		var tokenErr *TokenError
+		if !errors.As(err, &tokenErr) {
+			err = &TokenError{Source: fmt.Sprintf("%T", c.tokens), Err: err}
+		}
+		return "", err
+	}
+	return token, nil
+}
+
+// invalidator is implemented by the token sources caching the token, for the client to drop it when the API
+// rejects it.
+type invalidator interface {
+	Invalidate()
+}
+
+// invalidateToken drops the cached token, if any, after the API rejected it.
+func (c *Client) invalidateToken() {
+	if inv, ok := c.tokens.(invalidator); ok {
+		inv.Invalidate()
+	}
+}
+
+// StaticToken returns a TokenSource always returning the given token.
+func StaticToken(token string) TokenSource {
+	return staticToken(token)
+}
+
+type staticToken string
+
+func (t staticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+// EnvToken returns a TokenSource reading the token from the given environment variable on every request.
+func EnvToken(name string) TokenSource {
+	return envToken(name)
+}
+
+type envToken string
+
+func (t envToken) Token(ctx context.Context) (string, error) {
+	token := strings.TrimSpace(os.Getenv(string(t)))
+	if token == "" {
+		return "", &TokenError{Source: fmt.Sprintf("environment variable %s", string(t)), Err: errors.New("not set")}
+	}
+	return token, nil
+}
+
+// FileToken returns a TokenSource reading the token from the given file, i.e. a secret mounted by Kubernetes. The
+// spaces around the token are ignored. The file is read again whenever its modification time or size changes.
+func FileToken(filename string) TokenSource {
+	return &fileToken{filename: filename}
+}
+
+type fileToken struct {
+	filename string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+func (t *fileToken) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fail := func(err error) (string, error) {
+		return "", &TokenError{Source: fmt.Sprintf("file %q", t.filename), Err: err}
+	}
+	fi, err := os.Stat(t.filename)
+	if err != nil {
+		return fail(err)
+	}
+	if t.token != "" && fi.ModTime().Equal(t.modTime) && fi.Size() == t.size {
+		return t.token, nil
+	}
+	data, err := ioutil.ReadFile(t.filename)
+	if err != nil {
+		return fail(err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return fail(errors.New("the file is empty"))
+	}
+	t.token, t.modTime, t.size = token, fi.ModTime(), fi.Size()
+	return token, nil
+}
+
+// Invalidate makes the next call read the file again, even if it did not change.
+func (t *fileToken) Invalidate() {
+	t.mu.Lock()
+	t.token = ""
+	t.mu.Unlock()
+}
+
+// CommandToken returns a TokenSource running the given command on every call and using what it prints on its
+// standard output, the spaces around being ignored, as the token. It is meant to be wrapped with CacheToken:
+//
+//	slab.CacheToken(slab.CommandToken("vault", "kv", "get", "-field=token", "secret/slab"), 10*time.Minute)
+func CommandToken(name string, args ...string) TokenSource {
+	return &commandToken{name: name, args: args}
+}
+
+type commandToken struct {
+	name string
+	args []string
+}
+
+func (t *commandToken) Token(ctx context.Context) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.name, t.args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	if msg := strings.TrimSpace(stderr.String()); err != nil && msg != "" {
+		err = fmt.Errorf("%w: %s", err, msg)
+	}
+	token := strings.TrimSpace(stdout.String())
+	if err == nil && token == "" {
+		err = errors.New("the command printed no token")
+	}
+	if err != nil {
+		return "", &TokenError{Source: fmt.Sprintf("command %q", strings.Join(append([]string{t.name}, t.args...), " ")), Err: err}
+	}
+	return token, nil
+}
+
+// CacheToken returns a TokenSource caching the token of src for the given duration, or until the API rejects it
+// when ttl is zero. In both cases, the token is dropped as soon as the API rejects it so that the next request gets
+// a new one. The errors of src are not cached.
+func CacheToken(src TokenSource, ttl time.Duration) TokenSource {
+	return &cachedToken{src: src, ttl: ttl, now: time.Now}
+}
+
+type cachedToken struct {
+	src TokenSource
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (t *cachedToken) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && (t.ttl == 0 || t.now().Before(t.expires)) {
+		return t.token, nil
+	}
+	token, err := t.src.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	t.token, t.expires = token, t.now().Add(t.ttl)
+	return token, nil
+}
+
+// Invalidate drops the cached token, and the one cached by the wrapped source if it caches too.
+func (t *cachedToken) Invalidate() {
+	t.mu.Lock()
+	t.token = ""
+	t.mu.Unlock()
+	if inv, ok := t.src.(invalidator); ok {
+		inv.Invalidate()
+	}
+}
@@ -0,0 +1,184 @@
+package slab
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingToken returns "token<n>" on its nth call, or err when set.
+type countingToken struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (t *countingToken) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls++
+	if t.err != nil {
+		return "", t.err
+	}
+	return "token" + string(rune('0'+t.calls)), nil
+}
+
+func TestStaticToken(t *testing.T) {
+	token, err := StaticToken("abc").Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", token)
+}
+
+func TestEnvToken(t *testing.T) {
+	const name = "SLAB_GO_TEST_TOKEN"
+	defer os.Unsetenv(name)
+	src := EnvToken(name)
+
+	os.Unsetenv(name)
+	_, err := src.Token(context.Background())
+	assert.True(t, errors.Is(err, ErrToken))
+	assert.EqualError(t, err, "slab: can not get the API token from environment variable SLAB_GO_TEST_TOKEN: not set")
+
+	os.Setenv(name, " abc\n")
+	token, err := src.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", token)
+}
+
+func TestFileToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slab-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "token")
+	src := FileToken(filename)
+
+	_, err = src.Token(context.Background())
+	assert.True(t, errors.Is(err, ErrToken))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("first\n"), 0600))
+	token, err := src.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "first", token)
+
+	// Rotated by the secret manager
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("second\n"), 0600))
+	later := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(filename, later, later))
+	token, err = src.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "second", token)
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(" \n"), 0600))
+	_, err = src.Token(context.Background())
+	assert.EqualError(t, err, `slab: can not get the API token from file "`+filename+`": the file is empty`)
+}
+
+func TestCommandToken(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	token, err := CommandToken("sh", "-c", "echo ' abc '").Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", token)
+
+	_, err = CommandToken("sh", "-c", "echo denied >&2; exit 3").Token(context.Background())
+	assert.True(t, errors.Is(err, ErrToken))
+	var exitErr *exec.ExitError
+	assert.True(t, errors.As(err, &exitErr))
+	assert.EqualError(t, err, `slab: can not get the API token from command "sh -c echo denied >&2; exit 3": exit status 3: denied`)
+
+	_, err = CommandToken("sh", "-c", "true").Token(context.Background())
+	assert.Contains(t, err.Error(), "the command printed no token")
+}
+
+func TestCacheToken(t *testing.T) {
+	src := &countingToken{}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	cached := CacheToken(src, time.Minute).(*cachedToken)
+	cached.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		token, err := cached.Token(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "token1", token)
+	}
+	now = now.Add(time.Minute)
+	token, _ := cached.Token(context.Background())
+	assert.Equal(t, "token2", token)
+
+	cached.Invalidate()
+	token, _ = cached.Token(context.Background())
+	assert.Equal(t, "token3", token)
+
+	// The errors are not cached
+	cached.Invalidate()
+	src.err = errors.New("vault is sealed")
+	_, err := cached.Token(context.Background())
+	assert.EqualError(t, err, "vault is sealed")
+	src.err = nil
+	token, _ = cached.Token(context.Background())
+	assert.Equal(t, "token5", token)
+
+	// Without ttl, the token is kept until invalidated
+	forever := CacheToken(&countingToken{}, 0).(*cachedToken)
+	forever.now = func() time.Time { return now }
+	forever.Token(context.Background())
+	now = now.Add(24 * time.Hour)
+	token, _ = forever.Token(context.Background())
+	assert.Equal(t, "token1", token)
+}
+
+func TestClient_TokenSource(t *testing.T) {
+	var tokens []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "token1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"errors":[{"message":"invalid token"}]}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"post":{"id":"abc123"}}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	src := &countingToken{}
+	c := NewClient(&http.Client{}, "ignored", WithEndpoint(srv.URL), WithTokenSource(CacheToken(src, 0)))
+
+	_, err := c.Post.Get("abc123")
+	assert.True(t, IsUnauthorized(err), "%v", err)
+	// The rejected token is dropped from the cache
+	for i := 0; i < 2; i++ {
+		_, err = c.Post.Get("abc123")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{"token1", "token2", "token2"}, tokens)
+	assert.Equal(t, 2, src.calls)
+
+	// No request is sent when the source fails
+	tokens = nil
+	c = NewClient(&http.Client{}, "ignored", WithEndpoint(srv.URL), WithTokenSource(&countingToken{err: errors.New("vault is sealed")}))
+	_, err = c.Post.Get("abc123")
+	assert.True(t, errors.Is(err, ErrToken))
+	var tokenErr *TokenError
+	if assert.True(t, errors.As(err, &tokenErr)) {
+		assert.Equal(t, "*slab.countingToken", tokenErr.Source)
+	}
+	assert.EqualError(t, err, "slab: can not get the API token from *slab.countingToken: vault is sealed")
+	assert.Empty(t, tokens)
+}
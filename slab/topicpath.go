@@ -0,0 +1,118 @@
+package slab
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidTopicPath is matched by the errors returned for malformed topic paths.
+var ErrInvalidTopicPath = errors.New("slab: invalid topic path")
+
+// TopicPathError is returned when a topic path can not be parsed.
+type TopicPathError struct {
+	Path   string
+	Reason string
+}
+
+func (e *TopicPathError) Error() string {
+	return fmt.Sprintf("slab: invalid topic path %q: %s", e.Path, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrInvalidTopicPath) work.
+func (e *TopicPathError) Is(target error) bool {
+	return target == ErrInvalidTopicPath
+}
+
+// ParseTopicPath splits a path of topic names, i.e. "Engineering/Services", using the given separator.
+//
+// A separator preceded by a backslash is part of the name rather than a separator, and a double backslash stands for
+// a single one: `Engineering/CI\/CD` is the "CI/CD" topic under "Engineering". Any other backslash is kept as is.
+// The spaces around the names are trimmed and a *TopicPathError is returned for empty names, which includes leading,
+// trailing and doubled separators.
+func ParseTopicPath(path, separator string) ([]string, error) {
+	switch {
+	case separator == "":
+		return nil, &TopicPathError{Path: path, Reason: "empty separator"}
+	case strings.Contains(separator, `\`):
+		return nil, &TopicPathError{Path: path, Reason: "the separator can not contain a backslash"}
+	}
+
+	var names []string
+	var name strings.Builder
+	flush := func() error {
+		n := strings.TrimSpace(name.String())
+		if n == "" {
+			return &TopicPathError{Path: path, Reason: fmt.Sprintf("topic name %d is empty", len(names)+1)}
+		}
+		names = append(names, n)
+		name.Reset()
+		return nil
+	}
+	for i := 0; i < len(path); {
+		switch {
+		case path[i] == '\\' && strings.HasPrefix(path[i+1:], separator):
+			name.WriteString(separator)
+			i += 1 + len(separator)
+		case strings.HasPrefix(path[i:], `\\`):
+			name.WriteByte('\\')
+			i += 2
+		case strings.HasPrefix(path[i:], separator):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			i += len(separator)
+		default:
+			name.WriteByte(path[i])
+			i++
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// JoinTopicPath is the reverse of ParseTopicPath: it joins the names with the separator, escaping the separators and
+// the backslashes they contain.
+func JoinTopicPath(names []string, separator string) string {
+	escaped := make([]string, len(names))
+	for i, n := range names {
+		n = strings.Replace(n, `\`, `\\`, -1)
+		if separator != "" {
+			n = strings.Replace(n, separator, `\`+separator, -1)
+		}
+		escaped[i] = n
+	}
+	return strings.Join(escaped, separator)
+}
+
+// FoldTopicName returns the key under which topic names are compared: the spaces around the name are ignored and the
+// case is folded following Unicode, so that "STRASSE", "straße" and "Straße" are the same topic. The key is only
+// meant to be compared, not displayed.
+func FoldTopicName(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.TrimSpace(name) {
+		switch r {
+		case 'ß', 'ẞ':
+			// The only common full case folding not handled by the simple folding below
+			sb.WriteString("SS")
+			continue
+		}
+		// Use the smallest rune of the orbit of equivalent runes, i.e. 'K' for 'k' and the Kelvin sign
+		folded := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < folded {
+				folded = f
+			}
+		}
+		sb.WriteRune(folded)
+	}
+	return sb.String()
+}
+
+// sameTopicName tells whether two topic names designate the same topic.
+func sameTopicName(a, b string) bool {
+	return FoldTopicName(a) == FoldTopicName(b)
+}
@@ -0,0 +1,108 @@
+package slab
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTopicPath(t *testing.T) {
+	tests := []struct {
+		path, separator string
+		want            []string
+	}{
+		{"Engineering/Services/slab-go", "/", []string{"Engineering", "Services", "slab-go"}},
+		{"  Engineering /  Services ", "/", []string{"Engineering", "Services"}},
+		{`Engineering/CI\/CD`, "/", []string{"Engineering", "CI/CD"}},
+		{`Back\\slash/C:\Users`, "/", []string{`Back\slash`, `C:\Users`}},
+		{`Ends with\\`, "/", []string{`Ends with\`}},
+		{`Dangling\`, "/", []string{`Dangling\`}},
+		{"Engineering > Services", " > ", []string{"Engineering", "Services"}},
+		{`Engineering::Ops\::Tools`, "::", []string{"Engineering", "Ops::Tools"}},
+		{"Ingénierie/Équipe", "/", []string{"Ingénierie", "Équipe"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseTopicPath(tt.path, tt.separator)
+		if assert.NoError(t, err, tt.path) {
+			assert.Equal(t, tt.want, got, tt.path)
+		}
+	}
+
+	for _, tt := range []struct{ path, separator, reason string }{
+		{"", "/", "topic name 1 is empty"},
+		{"/Engineering", "/", "topic name 1 is empty"},
+		{"Engineering/", "/", "topic name 2 is empty"},
+		{"Engineering//Services", "/", "topic name 2 is empty"},
+		{"Engineering/  /Services", "/", "topic name 2 is empty"},
+		{"Engineering", "", "empty separator"},
+		{"Engineering", `\`, "the separator can not contain a backslash"},
+	} {
+		_, err := ParseTopicPath(tt.path, tt.separator)
+		assert.True(t, errors.Is(err, ErrInvalidTopicPath), tt.path)
+		var pathErr *TopicPathError
+		if assert.True(t, errors.As(err, &pathErr), tt.path) {
+			assert.Equal(t, tt.reason, pathErr.Reason, tt.path)
+			assert.Equal(t, tt.path, pathErr.Path)
+		}
+	}
+}
+
+func TestJoinTopicPath(t *testing.T) {
+	names := []string{"Engineering", "CI/CD", `Back\slash`}
+	path := JoinTopicPath(names, "/")
+	assert.Equal(t, `Engineering/CI\/CD/Back\\slash`, path)
+	got, err := ParseTopicPath(path, "/")
+	assert.NoError(t, err)
+	assert.Equal(t, names, got)
+}
+
+func TestFoldTopicName(t *testing.T) {
+	same := [][]string{
+		{"Engineering", "ENGINEERING", "engineering", " engineering "},
+		{"Straße", "STRASSE", "strasse", "STRAẞE"},
+		{"ΣΊΣΥΦΟΣ", "σίσυφος", "σίσυφοσ"},
+		{"Kelvin", "\u212aelvin"},
+	}
+	for _, names := range same {
+		for _, n := range names[1:] {
+			assert.Equal(t, FoldTopicName(names[0]), FoldTopicName(n), "%s and %s", names[0], n)
+		}
+	}
+	assert.NotEqual(t, FoldTopicName("Services"), FoldTopicName("Service"))
+	assert.NotEqual(t, FoldTopicName("resume"), FoldTopicName("résumé"))
+}
+
+func TestTopicService_AutoGenerate_InvalidPath(t *testing.T) {
+	c, calls, teardown := setupServer(t, testServer{})
+	defer teardown()
+
+	_, err := c.Topic.AutoGenerate("Engineering//Services", "/")
+	assert.True(t, errors.Is(err, ErrInvalidTopicPath))
+	_, err = c.Topic.PlanAutoGenerate("/", "/")
+	assert.True(t, errors.Is(err, ErrInvalidTopicPath))
+	_, err = c.Topic.NewResolver().Resolve(context.Background(), "", "/")
+	assert.True(t, errors.Is(err, ErrInvalidTopicPath))
+	assert.Empty(t, calls.requests(), "nothing must be sent for invalid paths")
+}
+
+func TestTopicService_AutoGenerate_Escaped(t *testing.T) {
+	c, calls, teardown := setupServer(t, testServer{responses: []string{
+		`{"data":{"organization":{"topics": [
+			{ "parent": null, "name": "engineering", "id": "bcd234" },
+			{ "parent": {"id": "bcd234"}, "name": "CI/CD", "id": "cde345" }
+		]}}}`,
+		`{"data":{"createTopic":{"name":"Build/Release","id":"zzz","description":""}}}`,
+	}})
+	defer teardown()
+
+	got, err := c.Topic.AutoGenerate(` Engineering / ci\/cd / Build\/Release `, "/")
+	if err != nil {
+		t.Errorf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, "zzz", got)
+	assert.Len(t, calls.requests(), 2)
+	assert.Equal(t, "Build/Release", calls.requests()[1].Variables["name"])
+	assert.Equal(t, "cde345", calls.requests()[1].Variables["parentId"])
+}
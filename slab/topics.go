@@ -2,7 +2,7 @@ package slab
 
 import (
 	"context"
-	"strings"
+	"errors"
 )
 
 // TopicService is an implementation of the service to interact with the posts
@@ -23,7 +23,13 @@ type Topic struct {
 }
 
 // List retrieves all the topics available in the organization including their details
+// For large organizations, use Iterate to fetch them in smaller chunks.
 func (t *TopicService) List() (*[]Topic, error) {
+	return t.ListContext(context.Background())
+}
+
+// ListContext is like List but uses the given context for the request.
+func (t *TopicService) ListContext(ctx context.Context) (*[]Topic, error) {
 	query := `{
 		organization {
 			topics{
@@ -42,7 +48,7 @@ func (t *TopicService) List() (*[]Topic, error) {
 	var resp struct {
 		Organization *Organization `json:"organization"`
 	}
-	err := t.client.Do(context.Background(), query, nil, &resp)
+	err := t.client.Do(ctx, query, nil, &resp)
 	if resp.Organization != nil {
 		return resp.Organization.Topics, err
 	}
@@ -51,6 +57,11 @@ func (t *TopicService) List() (*[]Topic, error) {
 
 // ListWithPosts retrieves all the topics available including the ID and the title of the posts they contain in the organization including their details
 func (t *TopicService) ListWithPosts() (*[]Topic, error) {
+	return t.ListWithPostsContext(context.Background())
+}
+
+// ListWithPostsContext is like ListWithPosts but uses the given context for the request.
+func (t *TopicService) ListWithPostsContext(ctx context.Context) (*[]Topic, error) {
 	query := `{
 		organization {
 			topics{
@@ -70,7 +81,7 @@ func (t *TopicService) ListWithPosts() (*[]Topic, error) {
 	var resp struct {
 		Organization *Organization `json:"organization"`
 	}
-	err := t.client.Do(context.Background(), query, nil, &resp)
+	err := t.client.Do(ctx, query, nil, &resp)
 	if resp.Organization != nil {
 		return resp.Organization.Topics, err
 	}
@@ -79,6 +90,11 @@ func (t *TopicService) ListWithPosts() (*[]Topic, error) {
 
 // Get retrieves the details of a specific topic
 func (t *TopicService) Get(id string) (*Topic, error) {
+	return t.GetContext(context.Background(), id)
+}
+
+// GetContext is like Get but uses the given context for the request.
+func (t *TopicService) GetContext(ctx context.Context, id string) (*Topic, error) {
 	query := `
 	query ($id: ID){
 		topic(id: $id){
@@ -98,12 +114,17 @@ func (t *TopicService) Get(id string) (*Topic, error) {
 		Topic *Topic `json:"topic"`
 	}
 	vars := map[string]interface{}{"id": id}
-	err := t.client.Do(context.Background(), query, vars, &resp)
+	err := t.client.Do(ctx, query, vars, &resp)
 	return resp.Topic, err
 }
 
 // Create inserts a new topic in slab
 func (t *TopicService) Create(name, description, parentID string) (*Topic, error) {
+	return t.CreateContext(context.Background(), name, description, parentID)
+}
+
+// CreateContext is like Create but uses the given context for the request.
+func (t *TopicService) CreateContext(ctx context.Context, name, description, parentID string) (*Topic, error) {
 	query := `
 	mutation (
 		$name: String!,
@@ -118,12 +139,17 @@ func (t *TopicService) Create(name, description, parentID string) (*Topic, error
 		Topic *Topic `json:"createTopic"`
 	}
 	vars := map[string]interface{}{"name": name, "description": description, "parentId": parentID}
-	err := t.client.Do(context.Background(), query, vars, &resp)
+	err := t.client.Do(ctx, query, vars, &resp)
 	return resp.Topic, err
 }
 
 // AddToPost attaches a topic to a post
 func (t *TopicService) AddToPost(topicID, postID string) (*Topic, error) {
+	return t.AddToPostContext(context.Background(), topicID, postID)
+}
+
+// AddToPostContext is like AddToPost but uses the given context for the request.
+func (t *TopicService) AddToPostContext(ctx context.Context, topicID, postID string) (*Topic, error) {
 	query := `
 	mutation($postId: ID!, $topicId: ID!){
 		addTopicToPost(postId: $postId, topicId: $topicId){ id, name, description }
@@ -132,12 +158,17 @@ func (t *TopicService) AddToPost(topicID, postID string) (*Topic, error) {
 		Topic *Topic `json:"addTopicToPost"`
 	}
 	vars := map[string]interface{}{"postId": postID, "topicId": topicID}
-	err := t.client.Do(context.Background(), query, vars, &resp)
+	err := t.client.Do(ctx, query, vars, &resp)
 	return resp.Topic, err
 }
 
 // RemoveFromPost detaches a topic from a post
 func (t *TopicService) RemoveFromPost(topicID, postID string) (*Topic, error) {
+	return t.RemoveFromPostContext(context.Background(), topicID, postID)
+}
+
+// RemoveFromPostContext is like RemoveFromPost but uses the given context for the request.
+func (t *TopicService) RemoveFromPostContext(ctx context.Context, topicID, postID string) (*Topic, error) {
 	query := `
 	mutation($postId: ID!, $topicId: ID!){
 		removeTopicFromPost(postId: $postId, topicId: $topicId){ id, name, description }
@@ -146,7 +177,7 @@ func (t *TopicService) RemoveFromPost(topicID, postID string) (*Topic, error) {
 		Topic *Topic `json:"removeTopicFromPost"`
 	}
 	vars := map[string]interface{}{"postId": postID, "topicId": topicID}
-	err := t.client.Do(context.Background(), query, vars, &resp)
+	err := t.client.Do(ctx, query, vars, &resp)
 	return resp.Topic, err
 }
 
@@ -162,46 +193,99 @@ func (t *TopicService) RemoveFromPost(topicID, postID string) (*Topic, error) {
 //
 // Note that the topic names are compared without taking the case in account so "EngineeRing" is the same as
 // "engineering" in our example.
+//
+// The hierarchy is parsed with ParseTopicPath: a separator can be part of a name by escaping it with a backslash, the
+// spaces around the names are ignored and an error matching ErrInvalidTopicPath is returned for empty names.
+//
+// The topics are listed on every call. To resolve many paths, possibly concurrently, use a TopicResolver instead.
 func (t *TopicService) AutoGenerate(topicHierarchy, separator string) (topicID string, err error) {
-	topics, err := t.List()
+	return t.AutoGenerateContext(context.Background(), topicHierarchy, separator)
+}
+
+// AutoGenerateContext is like AutoGenerate but uses the given context for the requests.
+func (t *TopicService) AutoGenerateContext(ctx context.Context, topicHierarchy, separator string) (topicID string, err error) {
+	names, err := ParseTopicPath(topicHierarchy, separator)
+	if err != nil {
+		return "", err
+	}
+	topics, err := t.ListContext(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	items := strings.Split(topicHierarchy, separator)
-	return t.autoCreate(nil, topics, items)
+	var list []Topic
+	if topics != nil {
+		list = *topics
+	}
+	existing, missing := MissingTopics(list, names)
+	if len(missing) == 0 {
+		return existing.ID, nil
+	}
+
+	// Create the missing topics under the deepest existing one
+	parentID := ""
+	if existing != nil {
+		parentID = existing.ID
+	}
+	for _, name := range missing {
+		created, err := t.CreateContext(ctx, name, "", parentID)
+		if err != nil {
+			return "", err
+		}
+		if created == nil {
+			return "", errors.New("slab: the created topic was not returned")
+		}
+		parentID = created.ID
+	}
+	return parentID, nil
 }
 
-// autoCreate is where the real work of the AutoGenerate function happens. It just needed to be recursive to be
-// efficient.
-func (t *TopicService) autoCreate(parent *Topic, tree *[]Topic, hierarchy []string) (topicID string, err error) {
-	item := hierarchy[0]
-	var found *Topic
+// PlanAutoGenerate returns the names of the topics AutoGenerate would create for the given hierarchy, from the top
+// most to the leaf, without creating them. It is empty when all the topics already exist.
+func (t *TopicService) PlanAutoGenerate(topicHierarchy, separator string) (missing []string, err error) {
+	return t.PlanAutoGenerateContext(context.Background(), topicHierarchy, separator)
+}
 
-	for _, topic := range *tree {
-		if ((parent == nil && topic.Parent == nil) || (parent != nil && topic.Parent != nil && topic.Parent.ID == parent.ID)) && strings.ToLower(topic.Name) == strings.ToLower(item) {
-			found = &topic
-			break
-		}
+// PlanAutoGenerateContext is like PlanAutoGenerate but uses the given context for the request.
+func (t *TopicService) PlanAutoGenerateContext(ctx context.Context, topicHierarchy, separator string) (missing []string, err error) {
+	names, err := ParseTopicPath(topicHierarchy, separator)
+	if err != nil {
+		return nil, err
 	}
+	topics, err := t.ListContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var list []Topic
+	if topics != nil {
+		list = *topics
+	}
+	_, missing = MissingTopics(list, names)
+	return missing, nil
+}
 
-	// Topic not found, create it and all its children
-	if found == nil {
-		parentID := ""
-		if parent != nil {
-			parentID = parent.ID
-		}
-		for _, i := range hierarchy {
-			if found, err = t.Create(i, "", parentID); err != nil {
-				return "", err
-			}
-			parentID = found.ID
+// MissingTopics matches the given path of topic names, as returned by ParseTopicPath, against the topics of the
+// organization like AutoGenerate does. It returns the deepest topic of the path that exists, nil if none does, and the
+// names of the topics below it that are missing. This allows to plan the topics to create for many paths while
+// listing the topics only once.
+func MissingTopics(topics []Topic, names []string) (existing *Topic, missing []string) {
+	for i, name := range names {
+		child := findChild(topics, existing, name)
+		if child == nil {
+			return existing, names[i:]
 		}
-
-		return found.ID, nil
+		existing = child
 	}
-	if len(hierarchy) == 1 { // Last loop, just return the topic
-		return found.ID, nil
+	return existing, nil
+}
+
+// findChild returns the topic of the given name, ignoring the case, whose parent is `parent`, or a top level topic
+// when `parent` is nil.
+func findChild(topics []Topic, parent *Topic, name string) *Topic {
+	for i, topic := range topics {
+		if ((parent == nil && topic.Parent == nil) || (parent != nil && topic.Parent != nil && topic.Parent.ID == parent.ID)) && sameTopicName(topic.Name, name) {
+			return &topics[i]
+		}
 	}
-	return t.autoCreate(found, tree, hierarchy[1:])
+	return nil
 }
@@ -232,7 +232,7 @@ func TestTopicService_AutoGenerate_NoCreate(t *testing.T) {
 }
 
 func TestTopicService_AutoGenerate_Create(t *testing.T) {
-	// This test is a bit more complex as there are multiple calls and we cannot change the apiEndpoint for now due to upstream not exposing it
+	// This test is a bit more complex as there are multiple calls to answer with different responses
 	callNbr := 0
 	expectedResponses := []string{
 		`{"data":{"organization":{"topics": [
@@ -257,9 +257,7 @@ func TestTopicService_AutoGenerate_Create(t *testing.T) {
 	// srv is the test server that will serve the endpoints
 	srv := httptest.NewServer(mux)
 	defer srv.Close()
-	apiEndpoint = srv.URL
-
-	c := NewClient(&http.Client{}, "dummy_token")
+	c := NewClient(&http.Client{}, "dummy_token", WithEndpoint(srv.URL))
 
 	want := "zzzNewSub"
 
@@ -271,3 +269,65 @@ func TestTopicService_AutoGenerate_Create(t *testing.T) {
 		t.Errorf("List returned: %s\nwant %s", got, want)
 	}
 }
+
+func TestTopicService_AutoGenerate_NullCreated(t *testing.T) {
+	c, calls, teardown := setupServer(t, testServer{responses: []string{
+		`{"data":{"organization":{"topics": [{ "parent": null, "name": "Engineering", "id": "bcd234" }]}}}`,
+		`{"data":{"createTopic":null}}`,
+	}})
+	defer teardown()
+
+	_, err := c.Topic.AutoGenerate("Engineering/NewTopic/NewSubTopic", "/")
+	assert.EqualError(t, err, "slab: the created topic was not returned")
+	assert.Len(t, calls.requests(), 2, "the sub topic is not created")
+}
+
+func TestTopicService_PlanAutoGenerate(t *testing.T) {
+	expectedResp := `{"data":{"organization":{"topics": [
+	            { "parent": null, "name": "Company", "id": "abc123", "children": [] },
+	            { "parent": null, "name": "Engineering", "id": "bcd234", "children": [{"id": "bcd234", "name": "Services"}] },
+	            { "parent": {"id": "bcd234"}, "name": "Services", "id": "cde345", "children": [{"id": "def456", "name": "App 1"}] },
+	            { "parent": {"id": "cde345"}, "name": "App 1", "id": "def456", "children": [] }
+            ]}}}`
+	c, _, teardown := setup(t, expectedResp)
+	defer teardown()
+
+	got, err := c.Topic.PlanAutoGenerate("engineering/NewTopic/NewSubTopic", "/")
+	if err != nil {
+		t.Errorf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, []string{"NewTopic", "NewSubTopic"}, got)
+
+	got, err = c.Topic.PlanAutoGenerate("Engineering/Services/App 1", "/")
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestMissingTopics(t *testing.T) {
+	topics := []Topic{
+		{ID: "1", Name: "Engineering"},
+		{ID: "2", Name: "Services", Parent: &Topic{ID: "1"}},
+		{ID: "3", Name: "Services"},
+	}
+	tests := []struct {
+		hierarchy string
+		existing  string
+		missing   []string
+	}{
+		{"Engineering/Services", "2", nil},
+		{"services", "3", nil},
+		{"Engineering/Services/App", "2", []string{"App"}},
+		{"Marketing/Services", "", []string{"Marketing", "Services"}},
+	}
+	for _, tt := range tests {
+		names, err := ParseTopicPath(tt.hierarchy, "/")
+		assert.NoError(t, err)
+		existing, missing := MissingTopics(topics, names)
+		id := ""
+		if existing != nil {
+			id = existing.ID
+		}
+		assert.Equal(t, tt.existing, id, tt.hierarchy)
+		assert.Equal(t, tt.missing, missing, tt.hierarchy)
+	}
+}
@@ -0,0 +1,225 @@
+package slab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTopicCycle is matched by the errors of topics being their own ancestors.
+var ErrTopicCycle = errors.New("slab: topic cycle")
+
+// SkipChildren can be returned by the function given to TopicTree.Walk to not visit the children of a topic.
+var SkipChildren = errors.New("skip the children of the topic")
+
+// TopicCycleError is returned when building a TopicTree from topics being their own ancestors.
+type TopicCycleError struct {
+	// IDs are the ids of the topics of the cycle, each one being the parent of the previous one.
+	IDs []string
+}
+
+func (e *TopicCycleError) Error() string {
+	return fmt.Sprintf("slab: topic cycle %s -> %s", strings.Join(e.IDs, " -> "), e.IDs[0])
+}
+
+// Is makes errors.Is(err, ErrTopicCycle) work.
+func (e *TopicCycleError) Is(target error) bool {
+	return target == ErrTopicCycle
+}
+
+// TopicTree is the hierarchy of the topics of an organization, built from a flat list like the one returned by
+// TopicService.List. Only the parent of the topics is used to build it.
+type TopicTree struct {
+	topics   map[string]*Topic
+	children map[string][]*Topic
+	roots    []*Topic
+	orphans  []*Topic
+}
+
+// NewTopicTree builds the tree of the given topics, keeping their order among siblings. The topics whose parent is
+// not part of the list are orphans: they can be listed with Orphans and are visited after the root ones by Walk.
+// A *TopicCycleError is returned if a topic is its own ancestor.
+func NewTopicTree(topics []Topic) (*TopicTree, error) {
+	t := &TopicTree{topics: make(map[string]*Topic, len(topics)), children: make(map[string][]*Topic)}
+	for i := range topics {
+		t.topics[topics[i].ID] = &topics[i]
+	}
+	for i := range topics {
+		topic := &topics[i]
+		switch {
+		case topic.Parent == nil || topic.Parent.ID == "":
+			t.roots = append(t.roots, topic)
+		case t.topics[topic.Parent.ID] == nil:
+			t.orphans = append(t.orphans, topic)
+		default:
+			t.children[topic.Parent.ID] = append(t.children[topic.Parent.ID], topic)
+		}
+	}
+	if err := t.checkCycles(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// checkCycles follows the parents of every topic to make sure they all lead to a root or an orphan.
+func (t *TopicTree) checkCycles() error {
+	done := make(map[string]bool, len(t.topics)) // topics known to be outside of a cycle
+	for id := range t.topics {
+		var chain []string
+		seen := make(map[string]int)
+		for cur := t.topics[id]; cur != nil && !done[cur.ID]; cur = t.Parent(cur.ID) {
+			if i, ok := seen[cur.ID]; ok {
+				return &TopicCycleError{IDs: chain[i:]}
+			}
+			seen[cur.ID] = len(chain)
+			chain = append(chain, cur.ID)
+		}
+		for _, c := range chain {
+			done[c] = true
+		}
+	}
+	return nil
+}
+
+// Len returns the number of topics of the tree.
+func (t *TopicTree) Len() int {
+	return len(t.topics)
+}
+
+// Get returns the topic of the given id, nil if it is not part of the tree.
+func (t *TopicTree) Get(id string) *Topic {
+	return t.topics[id]
+}
+
+// Root returns the top level topics.
+func (t *TopicTree) Root() []*Topic {
+	return t.roots
+}
+
+// Orphans returns the topics whose parent is not part of the tree, i.e. because it is not visible to the token.
+func (t *TopicTree) Orphans() []*Topic {
+	return t.orphans
+}
+
+// Children returns the direct children of the topic of the given id.
+func (t *TopicTree) Children(id string) []*Topic {
+	return t.children[id]
+}
+
+// Parent returns the parent of the topic of the given id, nil for the top level topics, the orphans and the unknown
+// ids.
+func (t *TopicTree) Parent(id string) *Topic {
+	topic := t.topics[id]
+	if topic == nil || topic.Parent == nil {
+		return nil
+	}
+	return t.topics[topic.Parent.ID]
+}
+
+// Path returns the names of the topics from the top level one to the topic of the given id, nil if the id is
+// unknown. The path of an orphan starts with it.
+func (t *TopicTree) Path(id string) []string {
+	var path []string
+	for topic := t.topics[id]; topic != nil; topic = t.Parent(topic.ID) {
+		path = append([]string{topic.Name}, path...)
+	}
+	return path
+}
+
+// Find returns the topic at the given path of topic names split with separator, i.e. "Engineering/Services" with
+// "/", starting from the top level topics. Like for AutoGenerate, the path is parsed with ParseTopicPath and the names
+// are compared without taking the case in account. nil is returned if there is no such topic or the path is invalid.
+func (t *TopicTree) Find(path, separator string) *Topic {
+	names, err := ParseTopicPath(path, separator)
+	if err != nil {
+		return nil
+	}
+	var found *Topic
+	for _, name := range names {
+		parentID := ""
+		if found != nil {
+			parentID = found.ID
+		}
+		if found = t.child(parentID, name); found == nil {
+			return nil
+		}
+	}
+	return found
+}
+
+// child returns the child of the given name, ignoring the case, of the topic of the given id, or the top level topic
+// of that name when parentID is empty.
+func (t *TopicTree) child(parentID, name string) *Topic {
+	siblings := t.roots
+	if parentID != "" {
+		siblings = t.children[parentID]
+	}
+	for _, topic := range siblings {
+		if sameTopicName(topic.Name, name) {
+			return topic
+		}
+	}
+	return nil
+}
+
+// add inserts a new topic in the tree, after its siblings. Its parent must already be part of the tree.
+func (t *TopicTree) add(topic Topic) {
+	if _, ok := t.topics[topic.ID]; ok {
+		return
+	}
+	t.topics[topic.ID] = &topic
+	if topic.Parent == nil {
+		t.roots = append(t.roots, &topic)
+	} else {
+		t.children[topic.Parent.ID] = append(t.children[topic.Parent.ID], &topic)
+	}
+}
+
+// Walk visits the topics depth first, the parents before their children, starting with the top level topics and
+// then the orphans. fn is called with the depth of the topic in its tree, 0 for the top level topics and the
+// orphans. If fn returns SkipChildren the children of the topic are not visited, any other error stops the walk
+// and is returned.
+func (t *TopicTree) Walk(fn func(topic *Topic, depth int) error) error {
+	for _, roots := range [][]*Topic{t.roots, t.orphans} {
+		for _, topic := range roots {
+			if err := t.walk(topic, 0, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *TopicTree) walk(topic *Topic, depth int, fn func(*Topic, int) error) error {
+	if err := fn(topic, depth); err != nil {
+		if err == SkipChildren {
+			return nil
+		}
+		return err
+	}
+	for _, child := range t.children[topic.ID] {
+		if err := t.walk(child, depth+1, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tree lists the topics of the organization and builds their tree.
+func (t *TopicService) Tree() (*TopicTree, error) {
+	return t.TreeContext(context.Background())
+}
+
+// TreeContext is like Tree but uses the given context for the request.
+func (t *TopicService) TreeContext(ctx context.Context) (*TopicTree, error) {
+	topics, err := t.ListContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var list []Topic
+	if topics != nil {
+		list = *topics
+	}
+	return NewTopicTree(list)
+}
@@ -0,0 +1,133 @@
+package slab
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testTopics() []Topic {
+	return []Topic{
+		{ID: "1", Name: "Engineering"},
+		{ID: "2", Name: "Services", Parent: &Topic{ID: "1"}},
+		{ID: "3", Name: "App 1", Parent: &Topic{ID: "2"}},
+		{ID: "4", Name: "Company"},
+		{ID: "5", Name: "Runbooks", Parent: &Topic{ID: "1"}},
+		{ID: "6", Name: "Hidden child", Parent: &Topic{ID: "unknown"}},
+		{ID: "7", Name: "App 2", Parent: &Topic{ID: "2"}},
+	}
+}
+
+func names(topics []*Topic) []string {
+	var n []string
+	for _, t := range topics {
+		n = append(n, t.Name)
+	}
+	return n
+}
+
+func TestTopicTree(t *testing.T) {
+	tree, err := NewTopicTree(testTopics())
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, 7, tree.Len())
+	assert.Equal(t, []string{"Engineering", "Company"}, names(tree.Root()))
+	assert.Equal(t, []string{"Hidden child"}, names(tree.Orphans()))
+	assert.Equal(t, []string{"Services", "Runbooks"}, names(tree.Children("1")))
+	assert.Empty(t, tree.Children("4"))
+	assert.Equal(t, "Services", tree.Parent("3").Name)
+	assert.Nil(t, tree.Parent("1"))
+	assert.Nil(t, tree.Parent("6"))
+	assert.Nil(t, tree.Parent("nope"))
+	assert.Equal(t, "App 1", tree.Get("3").Name)
+	assert.Nil(t, tree.Get("nope"))
+
+	assert.Equal(t, []string{"Engineering", "Services", "App 1"}, tree.Path("3"))
+	assert.Equal(t, []string{"Hidden child"}, tree.Path("6"))
+	assert.Nil(t, tree.Path("nope"))
+
+	assert.Equal(t, "3", tree.Find("engineering/SERVICES/app 1", "/").ID)
+	assert.Equal(t, "4", tree.Find("Company", "/").ID)
+	assert.Equal(t, "5", tree.Find("Engineering > Runbooks", " > ").ID)
+	assert.Nil(t, tree.Find("Services", "/"), "Find starts from the top level topics")
+	assert.Nil(t, tree.Find("Engineering/Nope", "/"))
+}
+
+func TestTopicTree_Walk(t *testing.T) {
+	tree, err := NewTopicTree(testTopics())
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+
+	var visited []string
+	err = tree.Walk(func(topic *Topic, depth int) error {
+		visited = append(visited, strings.Repeat("  ", depth)+topic.Name)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Engineering", "  Services", "    App 1", "    App 2", "  Runbooks", "Company", "Hidden child"}, visited)
+
+	visited = nil
+	err = tree.Walk(func(topic *Topic, depth int) error {
+		visited = append(visited, topic.Name)
+		if topic.Name == "Services" {
+			return SkipChildren
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Engineering", "Services", "Runbooks", "Company", "Hidden child"}, visited)
+
+	stop := errors.New("stop")
+	visited = nil
+	err = tree.Walk(func(topic *Topic, depth int) error {
+		visited = append(visited, topic.Name)
+		if topic.Name == "App 1" {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, []string{"Engineering", "Services", "App 1"}, visited)
+}
+
+func TestNewTopicTree_Cycle(t *testing.T) {
+	topics := append(testTopics(),
+		Topic{ID: "a", Name: "A", Parent: &Topic{ID: "c"}},
+		Topic{ID: "b", Name: "B", Parent: &Topic{ID: "a"}},
+		Topic{ID: "c", Name: "C", Parent: &Topic{ID: "b"}},
+		Topic{ID: "d", Name: "D", Parent: &Topic{ID: "c"}},
+	)
+	_, err := NewTopicTree(topics)
+	assert.True(t, errors.Is(err, ErrTopicCycle))
+	var cycle *TopicCycleError
+	if assert.True(t, errors.As(err, &cycle)) {
+		assert.Len(t, cycle.IDs, 3)
+		assert.Subset(t, []string{"a", "b", "c"}, cycle.IDs)
+	}
+
+	_, err = NewTopicTree([]Topic{{ID: "self", Name: "Self", Parent: &Topic{ID: "self"}}})
+	assert.EqualError(t, err, "slab: topic cycle self -> self")
+}
+
+func TestTopicService_Tree(t *testing.T) {
+	expectedResp := `{"data":{"organization":{"topics": [
+	            { "parent": null, "name": "Company", "id": "abc123", "children": [] },
+	            { "parent": null, "name": "Engineering", "id": "bcd234", "children": [{"id": "bcd234", "name": "Services"}] },
+	            { "parent": {"id": "bcd234"}, "name": "Services", "id": "cde345", "children": [{"id": "def456", "name": "App 1"}] },
+	            { "parent": {"id": "cde345"}, "name": "App 1", "id": "def456", "children": [] }
+            ]}}}`
+	c, _, teardown := setup(t, expectedResp)
+	defer teardown()
+
+	tree, err := c.Topic.Tree()
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, 4, tree.Len())
+	assert.Equal(t, []string{"Engineering", "Services", "App 1"}, tree.Path("def456"))
+	assert.Equal(t, "cde345", tree.Find("Engineering/Services", "/").ID)
+}
@@ -26,7 +26,13 @@ type Image struct {
 }
 
 // List retrieves all the users available in the organization including their details
+// For large organizations, use Iterate to fetch them in smaller chunks.
 func (p *UserService) List() (*[]User, error) {
+	return p.ListContext(context.Background())
+}
+
+// ListContext is like List but uses the given context for the request.
+func (p *UserService) ListContext(ctx context.Context) (*[]User, error) {
 	query := `{
 		organization {
 			users{
@@ -46,12 +52,20 @@ func (p *UserService) List() (*[]User, error) {
 	var resp struct {
 		Organization *Organization `json:"organization"`
 	}
-	err := p.client.Do(context.Background(), query, nil, &resp)
-	return resp.Organization.Users, err
+	err := p.client.Do(ctx, query, nil, &resp)
+	if resp.Organization != nil {
+		return resp.Organization.Users, err
+	}
+	return nil, err
 }
 
 // Get retrieves the details of a specific user including its content
 func (p *UserService) Get(id string) (*User, error) {
+	return p.GetContext(context.Background(), id)
+}
+
+// GetContext is like Get but uses the given context for the request.
+func (p *UserService) GetContext(ctx context.Context, id string) (*User, error) {
 	query := `
     query ($id: ID){
 		user(id: $id){
@@ -71,7 +85,6 @@ func (p *UserService) Get(id string) (*User, error) {
 		User *User `json:"user"`
 	}
 	vars := map[string]interface{}{"id": id}
-	err := p.client.Do(context.Background(), query, vars, &resp)
+	err := p.client.Do(ctx, query, vars, &resp)
 	return resp.User, err
-
 }
@@ -0,0 +1,88 @@
+package syncer
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// FrontMatter is the metadata that can be set at the top of a markdown file, either in YAML between `---` lines or
+// in TOML between `+++` lines:
+//
+//	---
+//	title: Deploying to production
+//	topics:
+//	  - Engineering/Runbooks
+//	  - Operations
+//	publish: false
+//	---
+//
+// The keys that are not listed here are ignored so that the files can be shared with static site generators.
+type FrontMatter struct {
+	// Title replaces the level 1 header the document starts with, or is inserted before the document when it does
+	// not start with one, as slab uses the first line of the content as the title.
+	Title string `yaml:"title" toml:"title"`
+	// Topics are the paths of the topics, separated by "/", the post is attached to. They replace the topic derived
+	// from the folder of the file.
+	Topics []string `yaml:"topics" toml:"topics"`
+	// ExternalID replaces the externalID derived from the path of the file. It is used as is, without the prefix of
+	// the Syncer, so when the file is removed its post is only deleted if the Syncer has a Manifest recording it.
+	ExternalID string `yaml:"externalId" toml:"externalId"`
+	// EditURL and ReadURL replace the urls returned by Syncer.URLs.
+	EditURL string `yaml:"editUrl" toml:"editUrl"`
+	ReadURL string `yaml:"readUrl" toml:"readUrl"`
+	// Publish, when set, publishes or unpublishes the post after it is synced.
+	Publish *bool `yaml:"publish" toml:"publish"`
+}
+
+// ParseFrontMatter extracts the front matter from the beginning of content and returns it with the rest of the
+// content. A nil FrontMatter is returned, with content unchanged, when there is none.
+func ParseFrontMatter(content []byte) (*FrontMatter, []byte, error) {
+	var delim string
+	switch {
+	case bytes.HasPrefix(content, []byte("---")):
+		delim = "---"
+	case bytes.HasPrefix(content, []byte("+++")):
+		delim = "+++"
+	default:
+		return nil, content, nil
+	}
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if string(bytes.TrimSpace(lines[0])) != delim {
+		return nil, content, nil
+	}
+	// Look for the closing delimiter, a document starting with a mere horizontal rule has none
+	offset := len(lines[0])
+	for _, l := range lines[1:] {
+		if string(bytes.TrimSpace(l)) == delim {
+			fm := &FrontMatter{}
+			raw := content[len(lines[0]):offset]
+			var err error
+			if delim == "---" {
+				err = yaml.Unmarshal(raw, fm)
+			} else {
+				err = toml.Unmarshal(raw, fm)
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("syncer: invalid front matter: %w", err)
+			}
+			return fm, bytes.TrimLeft(content[offset+len(l):], "\r\n"), nil
+		}
+		offset += len(l)
+	}
+	return nil, content, nil
+}
+
+// withTitle returns the markdown document with the given title as its first line.
+func withTitle(body []byte, title string) []byte {
+	header := []byte("# " + title + "\n")
+	if bytes.HasPrefix(body, []byte("# ")) {
+		if i := bytes.IndexByte(body, '\n'); i >= 0 {
+			return append(header, body[i+1:]...)
+		}
+		return header
+	}
+	return append(append(header, '\n'), body...)
+}
@@ -0,0 +1,200 @@
+package syncer
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VEVO/slab-go/internal/slabtest"
+	"github.com/VEVO/slab-go/slab"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *FrontMatter
+		body    string
+	}{
+		{
+			name: "yaml",
+			content: "---\ntitle: Deploying\ntopics:\n  - Engineering/Runbooks\n  - Operations\nexternalId: runbooks:deploy\n" +
+				"editUrl: https://example.com/edit\nreadUrl: https://example.com/read\npublish: false\nlayout: post\n---\n\n# Body\n",
+			want: &FrontMatter{
+				Title: "Deploying", Topics: []string{"Engineering/Runbooks", "Operations"}, ExternalID: "runbooks:deploy",
+				EditURL: "https://example.com/edit", ReadURL: "https://example.com/read", Publish: slab.Bool(false),
+			},
+			body: "# Body\n",
+		},
+		{
+			name:    "toml",
+			content: "+++\r\ntitle = \"Deploying\"\r\ntopics = [\"Operations\"]\r\npublish = true\r\n+++\r\n# Body\r\n",
+			want:    &FrontMatter{Title: "Deploying", Topics: []string{"Operations"}, Publish: slab.Bool(true)},
+			body:    "# Body\r\n",
+		},
+		{
+			name:    "empty",
+			content: "---\n---\nBody",
+			want:    &FrontMatter{},
+			body:    "Body",
+		},
+		{name: "none", content: "# Body\n---\nfoo: bar\n---\n", body: "# Body\n---\nfoo: bar\n---\n"},
+		{name: "horizontal rule", content: "---\nBody\n", body: "---\nBody\n"},
+		{name: "not a delimiter", content: "----\nfoo: bar\n----\n", body: "----\nfoo: bar\n----\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, body, err := ParseFrontMatter([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("Expecting no error, got: %v", err)
+			}
+			assert.Equal(t, tt.want, fm)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+
+	_, _, err := ParseFrontMatter([]byte("---\ntopics: [unclosed\n---\n"))
+	assert.Error(t, err)
+	_, _, err = ParseFrontMatter([]byte("+++\ntitle = \n+++\n"))
+	assert.Error(t, err)
+}
+
+func TestWithTitle(t *testing.T) {
+	assert.Equal(t, "# Title\n\nBody\n", string(withTitle([]byte("Body\n"), "Title")))
+	assert.Equal(t, "# Title\nBody\n", string(withTitle([]byte("# Old\nBody\n"), "Title")))
+	assert.Equal(t, "# Title\n", string(withTitle([]byte("# Old"), "Title")))
+	assert.Equal(t, "# Title\n\n## Sub\n", string(withTitle([]byte("## Sub\n"), "Title")))
+}
+
+func TestSyncer_Run_FrontMatter(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	dir := writeTree(t, map[string]string{
+		"guides/deploy.md": "---\ntitle: Deploying to production\ntopics: [Engineering/Runbooks, Operations]\n" +
+			"externalId: runbooks:deploy\neditUrl: https://example.com/custom-edit\npublish: false\n---\n# Deploy\nSteps\n",
+		"guides/draft.md": "+++\npublish = true\n+++\nDraft\n",
+		"plain.md":        "---\nNot front matter\n",
+		"page.html":       "---\ntitle: ignored\n---\n<p>HTML</p>",
+	})
+	defer os.RemoveAll(dir)
+
+	m := NewManifest()
+	s := &Syncer{Client: srv.Client(), Root: dir, Prefix: "repo:", URLs: testURLs, Manifest: m}
+	files, err := s.Scan()
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, "runbooks:deploy", files[0].ExternalID)
+	assert.Equal(t, []string{"Engineering/Runbooks", "Operations"}, files[0].Topics)
+
+	res, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Len(t, res.Synced, 4)
+
+	deploy := srv.PostByExternalID("runbooks:deploy")
+	if assert.NotNil(t, deploy) {
+		assert.Equal(t, "# Deploying to production\nSteps\n", deploy.Content)
+		assert.Equal(t, "Deploying to production", deploy.Title)
+		assert.Equal(t, "https://example.com/custom-edit", deploy.EditURL)
+		assert.Equal(t, "https://example.com/blob/guides/deploy.md", deploy.ReadURL)
+		assert.False(t, deploy.Published)
+		var topics []string
+		for _, id := range deploy.Topics {
+			topics = append(topics, srv.TopicPath(id))
+		}
+		assert.Equal(t, []string{"Engineering/Runbooks", "Operations"}, topics)
+	}
+	assert.Nil(t, res.Synced["runbooks:deploy"].PublishedAt)
+	assert.Equal(t, []string{"Engineering/Runbooks", "Operations"}, m.Entries["runbooks:deploy"].Topics)
+
+	draft := srv.PostByExternalID("repo:guides/draft.md")
+	if assert.NotNil(t, draft) {
+		assert.Equal(t, "Draft\n", draft.Content)
+		assert.True(t, draft.Published)
+		assert.Equal(t, "guides", srv.TopicPath(draft.Topics[0]))
+	}
+	assert.Equal(t, "---\nNot front matter\n", srv.PostByExternalID("repo:plain.md").Content)
+	assert.Equal(t, "---\ntitle: ignored\n---\n<p>HTML</p>", srv.PostByExternalID("repo:page.html").Content)
+	// Only deploy.md needed to be unpublished
+	assert.Equal(t, 1, srv.Calls["updatePost"])
+}
+
+func TestSyncer_Run_FrontMatterChanges(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	dir := writeTree(t, map[string]string{
+		"deploy.md": "---\ntopics: [Runbooks, Operations]\nexternalId: runbooks:deploy\n---\n# Deploy\n",
+	})
+	defer os.RemoveAll(dir)
+
+	m := NewManifest()
+	s := &Syncer{Client: srv.Client(), Root: dir, Prefix: "repo:", URLs: testURLs, Manifest: m}
+	_, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	topicPaths := func() []string {
+		var paths []string
+		for _, id := range srv.PostByExternalID("runbooks:deploy").Topics {
+			paths = append(paths, srv.TopicPath(id))
+		}
+		return paths
+	}
+	assert.Equal(t, []string{"Runbooks", "Operations"}, topicPaths())
+	assert.Len(t, m.Entries["runbooks:deploy"].TopicIDs, 2)
+
+	// The topic removed from the front matter is detached from the post
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "deploy.md"),
+		[]byte("---\ntopics: [Runbooks]\nexternalId: runbooks:deploy\n---\n# Deploy\n"), 0644))
+	res, err := s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, res.Synced, 1)
+	assert.Equal(t, []string{"Runbooks"}, topicPaths())
+
+	// A change of url syncs the post again
+	s.URLs = func(relPath string) (string, string) {
+		return "https://example.com/moved/" + relPath, "https://example.com/moved/" + relPath
+	}
+	res, err = s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, res.Synced, 1)
+	assert.Equal(t, "https://example.com/moved/deploy.md", srv.PostByExternalID("runbooks:deploy").EditURL)
+	res, err = s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"runbooks:deploy"}, res.Skipped)
+
+	// The externalID does not start with the prefix, but the manifest tells it was synced from this directory
+	assert.NoError(t, os.Remove(filepath.Join(dir, "deploy.md")))
+	plan, err := s.Plan(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "  - runbooks:deploy\n\nPlan: 0 to create, 0 to update, 1 to delete, 0 topic(s) to create. 0 unchanged.\n",
+		plan.String())
+	res, err = s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"runbooks:deploy"}, res.Deleted)
+	assert.Nil(t, srv.PostByExternalID("runbooks:deploy"))
+	assert.Empty(t, m.Entries)
+}
+
+func TestSyncer_Scan_FrontMatterErrors(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"a.md": "---\nexternalId: b.md\n---\nA",
+		"b.md": "B",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := (&Syncer{Root: dir}).Scan()
+	assert.EqualError(t, err, `syncer: a.md and b.md have the same externalID "b.md"`)
+
+	dir2 := writeTree(t, map[string]string{"a.md": "---\ntopics: {\n---\nA"})
+	defer os.RemoveAll(dir2)
+	_, err = (&Syncer{Root: dir2}).Scan()
+	assert.Error(t, err)
+}
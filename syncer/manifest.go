@@ -0,0 +1,111 @@
+package syncer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// manifestVersion is the version of the manifest file format.
+const manifestVersion = 1
+
+// Manifest keeps the state of the previous sync runs so that the unchanged documents are not synced again.
+// It is persisted as a JSON file, typically committed next to the synced directory or cached between CI runs.
+type Manifest struct {
+	// Version is the version of the file format.
+	Version int `json:"version"`
+	// Entries maps the externalIDs of the synced documents to their state.
+	Entries map[string]*ManifestEntry `json:"entries"`
+}
+
+// ManifestEntry is the state of a document after it was last synced.
+type ManifestEntry struct {
+	// Path is the path of the file relative to the synced directory, using forward slashes.
+	Path string `json:"path"`
+	// Hash is the hex encoded SHA-256 of the content of the file.
+	Hash string `json:"hash"`
+	// Topics are the paths of the topics the post was placed under.
+	Topics []string `json:"topics,omitempty"`
+	// TopicIDs are the ids of those topics, for the post to be removed from the ones its file does not list anymore.
+	TopicIDs []string `json:"topicIds,omitempty"`
+	// EditURL and ReadURL are the urls the post was synced with.
+	EditURL string `json:"editUrl,omitempty"`
+	ReadURL string `json:"readUrl,omitempty"`
+	PostID  string `json:"postId"`
+	// Version is the version of the post returned by slab when it was synced.
+	Version  int       `json:"version"`
+	SyncedAt time.Time `json:"syncedAt"`
+}
+
+// NewManifest returns an empty manifest.
+func NewManifest() *Manifest {
+	return &Manifest{Version: manifestVersion, Entries: make(map[string]*ManifestEntry)}
+}
+
+// LoadManifest reads the manifest stored in the given file. An empty manifest is returned when the file does not
+// exist yet.
+func LoadManifest(filename string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return NewManifest(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := NewManifest()
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("syncer: invalid manifest %s: %w", filename, err)
+	}
+	if m.Version > manifestVersion {
+		return nil, fmt.Errorf("syncer: manifest %s has version %d, only %d is supported", filename, m.Version, manifestVersion)
+	}
+	if m.Entries == nil {
+		m.Entries = make(map[string]*ManifestEntry)
+	}
+	return m, nil
+}
+
+// Save writes the manifest to the given file. The file is replaced atomically so that an interrupted save does not
+// lose the previous state.
+func (m *Manifest) Save(filename string) error {
+	m.Version = manifestVersion
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// ExternalIDs returns the externalIDs of the entries, sorted.
+func (m *Manifest) ExternalIDs() []string {
+	ids := make([]string, 0, len(m.Entries))
+	for id := range m.Entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// hashContent returns the hex encoded SHA-256 of content.
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
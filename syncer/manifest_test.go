@@ -0,0 +1,61 @@
+package syncer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "manifest.json")
+
+	m, err := LoadManifest(filename)
+	if err != nil {
+		t.Fatalf("Expecting no error for a missing manifest, got: %v", err)
+	}
+	assert.Equal(t, NewManifest(), m)
+
+	syncedAt := time.Date(2020, 5, 4, 10, 30, 0, 0, time.UTC)
+	m.Entries["repo:a.md"] = &ManifestEntry{
+		Path: "a.md", Hash: hashContent([]byte("# A")), PostID: "post-1", Version: 3, SyncedAt: syncedAt,
+	}
+	if err := m.Save(filename); err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 1, "the temporary file must be removed")
+
+	got, err := LoadManifest(filename)
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, m, got)
+	assert.Equal(t, []string{"repo:a.md"}, got.ExternalIDs())
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(`{"version": 1}`), 0644))
+	got, err = LoadManifest(filename)
+	assert.NoError(t, err)
+	assert.NotNil(t, got.Entries)
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(`{"version": 2}`), 0644))
+	_, err = LoadManifest(filename)
+	assert.Error(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(`not json`), 0644))
+	_, err = LoadManifest(filename)
+	assert.Error(t, err)
+}
+
+func TestHashContent(t *testing.T) {
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", hashContent(nil))
+	assert.NotEqual(t, hashContent([]byte("a")), hashContent([]byte("b")))
+}
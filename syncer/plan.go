@@ -0,0 +1,194 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/VEVO/slab-go/slab"
+)
+
+// Action is what a sync run would do with a document.
+type Action string
+
+// The actions of a plan.
+const (
+	// ActionCreate syncs a file which was never synced before.
+	ActionCreate Action = "create"
+	// ActionUpdate syncs a file which was synced before.
+	ActionUpdate Action = "update"
+	// ActionDelete deletes the post of a file which disappeared.
+	ActionDelete Action = "delete"
+	// ActionNone leaves the post of an unchanged file as is.
+	ActionNone Action = "none"
+)
+
+// Change is the planned action for a document.
+type Change struct {
+	Action     Action
+	ExternalID string
+	// File is the file of the document, nil for the deletions.
+	File *File
+	// Reason explains why an update is needed: "changed", "drifted" or "unknown" when there is no manifest to tell.
+	Reason string
+}
+
+// Plan describes what a sync run would do, without doing it.
+type Plan struct {
+	// Changes lists the actions per document, sorted by externalID.
+	Changes []Change
+	// Topics lists the paths of the topics that would be created, separated by "/" and sorted.
+	Topics []string
+}
+
+// Plan computes what Run would do, only issuing queries to slab: the topics are listed to know which ones would be
+// created and, with DetectDrift, the posts of the unchanged files are fetched.
+//
+// Without a Manifest, the files whose externalID is not in KnownExternalIDs are planned as creations and the others
+// as updates.
+func (s *Syncer) Plan(ctx context.Context) (*Plan, error) {
+	files, err := s.Scan()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	present := make(map[string]bool)
+	known := make(map[string]bool)
+	for _, id := range s.knownExternalIDs() {
+		known[id] = true
+	}
+	topics := make(map[string]bool)
+	for i := range files {
+		f := &files[i]
+		present[f.ExternalID] = true
+		c := Change{Action: ActionCreate, ExternalID: f.ExternalID, File: f}
+		content, err := ioutil.ReadFile(filepath.Join(s.Root, filepath.FromSlash(f.Path)))
+		if err != nil {
+			return nil, err
+		}
+		editURL, readURL := s.urls(*f)
+		switch {
+		case s.unchanged(*f, hashContent(content), editURL, readURL):
+			drifted, err := s.drifted(ctx, *f)
+			if err != nil {
+				return nil, fmt.Errorf("checking %s for drift: %w", f.Path, err)
+			}
+			c.Action = ActionNone
+			if drifted {
+				c.Action, c.Reason = ActionUpdate, "drifted"
+			}
+		case s.Manifest != nil && s.Manifest.Entries[f.ExternalID] != nil:
+			c.Action, c.Reason = ActionUpdate, "changed"
+		case known[f.ExternalID]:
+			c.Action, c.Reason = ActionUpdate, "unknown"
+		}
+		if c.Action != ActionNone {
+			for _, t := range f.Topics {
+				topics[t] = true
+			}
+		}
+		plan.Changes = append(plan.Changes, c)
+	}
+
+	for id := range known {
+		if !present[id] && s.owned(id) {
+			plan.Changes = append(plan.Changes, Change{Action: ActionDelete, ExternalID: id})
+		}
+	}
+	sort.Slice(plan.Changes, func(i, j int) bool { return plan.Changes[i].ExternalID < plan.Changes[j].ExternalID })
+
+	if len(topics) > 0 {
+		if plan.Topics, err = s.planTopics(ctx, topics); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// planTopics returns the paths of the topics AutoGenerate would create for the given topic paths.
+func (s *Syncer) planTopics(ctx context.Context, paths map[string]bool) ([]string, error) {
+	list, err := s.Client.Topic.ListContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var existing []slab.Topic
+	if list != nil {
+		existing = *list
+	}
+	created := make(map[string]bool)
+	for p := range paths {
+		names, err := slab.ParseTopicPath(p, "/")
+		if err != nil {
+			return nil, err
+		}
+		_, missing := slab.MissingTopics(existing, names)
+		// Every missing topic is a path prefix of p
+		for i := range missing {
+			created[slab.JoinTopicPath(names[:len(names)-len(missing)+i+1], "/")] = true
+		}
+	}
+	topics := make([]string, 0, len(created))
+	for p := range created {
+		topics = append(topics, p)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+// Count returns the number of changes with the given action.
+func (p *Plan) Count(a Action) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == a {
+			n++
+		}
+	}
+	return n
+}
+
+// Empty tells whether applying the plan would not change anything.
+func (p *Plan) Empty() bool {
+	return len(p.Topics) == 0 && p.Count(ActionNone) == len(p.Changes)
+}
+
+// WriteTo writes a summary of the plan to w, in the fashion of terraform: one line per change prefixed with `+` for
+// creations, `~` for updates and `-` for deletions, followed by the totals.
+func (p *Plan) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	for _, t := range p.Topics {
+		fmt.Fprintf(&sb, "  + topic %s\n", t)
+	}
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionCreate:
+			fmt.Fprintf(&sb, "  + %s (%s)\n", c.ExternalID, c.File.Path)
+		case ActionUpdate:
+			fmt.Fprintf(&sb, "  ~ %s (%s, %s)\n", c.ExternalID, c.File.Path, c.Reason)
+		case ActionDelete:
+			fmt.Fprintf(&sb, "  - %s\n", c.ExternalID)
+		}
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("\n")
+	}
+	if p.Empty() {
+		fmt.Fprintf(&sb, "No changes. %d document(s) up to date.\n", p.Count(ActionNone))
+	} else {
+		fmt.Fprintf(&sb, "Plan: %d to create, %d to update, %d to delete, %d topic(s) to create. %d unchanged.\n",
+			p.Count(ActionCreate), p.Count(ActionUpdate), p.Count(ActionDelete), len(p.Topics), p.Count(ActionNone))
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// String returns the summary written by WriteTo.
+func (p *Plan) String() string {
+	var sb strings.Builder
+	_, _ = p.WriteTo(&sb)
+	return sb.String()
+}
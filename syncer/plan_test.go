@@ -0,0 +1,90 @@
+package syncer
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VEVO/slab-go/internal/slabtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncer_Plan(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	eng := srv.AddTopic("Engineering", "")
+	srv.AddTopic("Repo", eng)
+
+	dir := writeTree(t, map[string]string{"a.md": "# A", "b.md": "# B", "guides/c.md": "# C", "guides/deep/d.md": "# D"})
+	defer os.RemoveAll(dir)
+
+	m := NewManifest()
+	s := &Syncer{Client: srv.Client(), Root: dir, Prefix: "repo:", Topic: "Engineering/Repo", URLs: testURLs, Manifest: m}
+	plan, err := s.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, 4, plan.Count(ActionCreate))
+	assert.Equal(t, []string{"Engineering/Repo/guides", "Engineering/Repo/guides/deep"}, plan.Topics)
+	assert.Equal(t, "  + topic Engineering/Repo/guides\n"+
+		"  + topic Engineering/Repo/guides/deep\n"+
+		"  + repo:a.md (a.md)\n"+
+		"  + repo:b.md (b.md)\n"+
+		"  + repo:guides/c.md (guides/c.md)\n"+
+		"  + repo:guides/deep/d.md (guides/deep/d.md)\n"+
+		"\n"+
+		"Plan: 4 to create, 0 to update, 0 to delete, 2 topic(s) to create. 0 unchanged.\n", plan.String())
+	assert.Empty(t, srv.Posts, "planning must not sync anything")
+	assert.Len(t, srv.Topics, 2, "planning must not create topics")
+
+	_, err = s.Run(context.Background())
+	assert.NoError(t, err)
+	plan, err = s.Plan(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty())
+	assert.Equal(t, "No changes. 4 document(s) up to date.\n", plan.String())
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.md"), []byte("# A2"), 0644))
+	assert.NoError(t, os.Remove(filepath.Join(dir, "b.md")))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "new.md"), []byte("# E"), 0644))
+	s.DetectDrift = true
+	srv.Mu.Lock()
+	srv.Posts[m.Entries["repo:guides/c.md"].PostID].Version++
+	srv.Mu.Unlock()
+	syncs := srv.Calls["syncPost"]
+
+	plan, err = s.Plan(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "  ~ repo:a.md (a.md, changed)\n"+
+		"  - repo:b.md\n"+
+		"  ~ repo:guides/c.md (guides/c.md, drifted)\n"+
+		"  + repo:new.md (new.md)\n"+
+		"\n"+
+		"Plan: 1 to create, 2 to update, 1 to delete, 0 topic(s) to create. 1 unchanged.\n", plan.String())
+	assert.Equal(t, syncs, srv.Calls["syncPost"])
+	assert.Zero(t, srv.Calls["deletePost"])
+}
+
+func TestSyncer_Plan_WithoutManifest(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	dir := writeTree(t, map[string]string{"a.md": "# A", "b.md": "# B"})
+	defer os.RemoveAll(dir)
+
+	s := &Syncer{Client: srv.Client(), Root: dir, URLs: testURLs, KnownExternalIDs: []string{"a.md", "gone.md"}}
+	plan, err := s.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	want := []Change{
+		{Action: ActionUpdate, ExternalID: "a.md", File: &File{Path: "a.md", ExternalID: "a.md", Format: "MARKDOWN"}, Reason: "unknown"},
+		{Action: ActionCreate, ExternalID: "b.md", File: &File{Path: "b.md", ExternalID: "b.md", Format: "MARKDOWN"}},
+		{Action: ActionDelete, ExternalID: "gone.md"},
+	}
+	assert.Equal(t, want, plan.Changes)
+	assert.Empty(t, plan.Topics)
+	assert.Zero(t, srv.Calls["organization"], "topics are only listed when needed")
+}
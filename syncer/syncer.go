@@ -0,0 +1,431 @@
+// Package syncer mirrors a directory of markdown and HTML documents into slab posts.
+//
+// Each file is synced with slab's syncPost mutation using an externalID derived from its path relative to the
+// synced directory, so that the same file always updates the same post. The posts are placed under a topic
+// derived from the folder they are in, and the posts whose file disappeared are deleted. The markdown files can
+// override those defaults with a front matter, see FrontMatter.
+//
+//	s := &syncer.Syncer{
+//		Client: client,
+//		Root:   "docs",
+//		Prefix: "my-repo:",
+//		Topic:  "Engineering/My repo",
+//		URLs: func(path string) (string, string) {
+//			u := "https://github.com/me/my-repo/blob/master/docs/" + path
+//			return u, u
+//		},
+//	}
+//	res, err := s.Run(ctx)
+//
+// To avoid syncing the unchanged files on every run, and bumping the version of their post, a Manifest recording
+// the hash of the synced files can be kept between runs:
+//
+//	m, err := syncer.LoadManifest("slab-manifest.json")
+//	...
+//	s.Manifest = m
+//	res, err := s.Run(ctx)
+//	...
+//	err = m.Save("slab-manifest.json")
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VEVO/slab-go/slab"
+)
+
+// URLFunc returns the urls of the source of the file at the given path, relative to the synced directory and using
+// forward slashes. editURL is where the "Edit Post" button of slab leads, readURL where the original document can be
+// read. At least editURL must be returned.
+type URLFunc func(relPath string) (editURL, readURL string)
+
+// Syncer mirrors a directory into slab posts. Only Client, Root and URLs are required.
+type Syncer struct {
+	Client *slab.Client
+	// Root is the directory to sync.
+	Root string
+	// Prefix is prepended to the path of the files to build their externalID. It must be unique to the synced
+	// directory so that several directories can be synced in the same organization.
+	Prefix string
+	// Topic is the path of the topic, separated by "/" and escaped as described by slab.ParseTopicPath, under which
+	// the topics of the folders are created.
+	// The files at the root of the directory are attached to it. When empty, the folders of the first level become
+	// top level topics and the files at the root are not attached to any topic.
+	Topic string
+	// URLs returns the edit and read urls of each file.
+	URLs URLFunc
+	// KnownExternalIDs are the externalIDs synced by the previous runs. The ones that do not match a file anymore
+	// are deleted from slab. Only the ones starting with Prefix are considered, as nothing tells they were synced
+	// from this directory otherwise.
+	KnownExternalIDs []string
+	// Manifest, when set, records the state of the synced documents. The files whose content, topics and urls did
+	// not change since they were recorded are not synced again. The externalIDs of the manifest are known ones and,
+	// having been synced by this Syncer, are deleted when their file disappears even if they do not start with
+	// Prefix, as with an externalId set by a front matter.
+	// Run updates it, it is up to the caller to save it.
+	Manifest *Manifest
+	// DetectDrift makes Run check that the posts of the unchanged files still have the version recorded in the
+	// Manifest. The posts modified or deleted on slab since are synced again and reported in Result.Drifted.
+	DetectDrift bool
+	// Logf, when set, is called to report the progress of the sync.
+	Logf func(format string, args ...interface{})
+}
+
+// File is a document found in the synced directory.
+type File struct {
+	// Path is the path of the file relative to the synced directory, using forward slashes.
+	Path       string
+	ExternalID string
+	// Format is the format of the file as expected by PostService.Sync: `MARKDOWN` or `HTML`.
+	Format string
+	// Topics are the paths of the topics the post is placed under, separated by "/".
+	Topics []string
+	// Title, EditURL, ReadURL and Publish are set from the front matter of the file, if any.
+	Title   string
+	EditURL string
+	ReadURL string
+	Publish *bool
+}
+
+// Result reports what a sync run did.
+type Result struct {
+	// Synced maps the externalIDs of the synced files to the resulting posts.
+	Synced map[string]*slab.Post
+	// Skipped lists the externalIDs of the files which did not change since the last run according to the Manifest.
+	Skipped []string
+	// Drifted lists the externalIDs of the posts which changed on slab independently of their file. They are synced
+	// again and are part of Synced too.
+	Drifted []string
+	// Deleted lists the externalIDs of the deleted posts.
+	Deleted []string
+	// Failed maps the externalIDs that could not be synced or deleted to the error encountered.
+	Failed map[string]error
+}
+
+// formats maps the supported file extensions to the format of their content.
+var formats = map[string]string{
+	".md":       "MARKDOWN",
+	".markdown": "MARKDOWN",
+	".html":     "HTML",
+	".htm":      "HTML",
+}
+
+// Scan lists the documents of the directory, sorted by path. Hidden files and directories are skipped.
+func (s *Syncer) Scan() ([]File, error) {
+	var files []File
+	err := filepath.Walk(s.Root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != s.Root && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		format, ok := formats[strings.ToLower(filepath.Ext(p))]
+		if info.IsDir() || !ok {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		f := File{Path: rel, ExternalID: s.ExternalID(rel), Format: format}
+		if topic := s.topicOf(rel); topic != "" {
+			f.Topics = []string{topic}
+		}
+		if format == "MARKDOWN" {
+			if err := s.applyFrontMatter(&f); err != nil {
+				return err
+			}
+		}
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	paths := make(map[string]string)
+	for _, f := range files {
+		if other, ok := paths[f.ExternalID]; ok {
+			return nil, fmt.Errorf("syncer: %s and %s have the same externalID %q", other, f.Path, f.ExternalID)
+		}
+		paths[f.ExternalID] = f.Path
+	}
+	return files, nil
+}
+
+// applyFrontMatter reads the front matter of the file and overrides the defaults with it.
+func (s *Syncer) applyFrontMatter(f *File) error {
+	content, err := ioutil.ReadFile(filepath.Join(s.Root, filepath.FromSlash(f.Path)))
+	if err != nil {
+		return err
+	}
+	fm, _, err := ParseFrontMatter(content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.Path, err)
+	}
+	if fm == nil {
+		return nil
+	}
+	if fm.ExternalID != "" {
+		f.ExternalID = fm.ExternalID
+	}
+	if fm.Topics != nil {
+		f.Topics = fm.Topics
+	}
+	f.Title, f.EditURL, f.ReadURL, f.Publish = fm.Title, fm.EditURL, fm.ReadURL, fm.Publish
+	return nil
+}
+
+// ExternalID returns the externalID of the file at the given path, relative to the synced directory.
+func (s *Syncer) ExternalID(relPath string) string {
+	return s.Prefix + filepath.ToSlash(relPath)
+}
+
+// topicOf returns the topic path of a file from the folder it is in.
+func (s *Syncer) topicOf(relPath string) string {
+	dir := path.Dir(relPath)
+	if dir == "." {
+		return s.Topic
+	}
+	// The folder names may contain backslashes, which would be taken for escapes
+	dir = slab.JoinTopicPath(strings.Split(dir, "/"), "/")
+	if s.Topic == "" {
+		return dir
+	}
+	return s.Topic + "/" + dir
+}
+
+// Run syncs all the documents of the directory, then deletes the posts of the known externalIDs whose file has
+// disappeared. When the Manifest recorded the topics of a post, the topics removed from its file since are
+// detached from it. The failure of a single document does not stop the run: it is reported in Result.Failed and the
+// returned error.
+func (s *Syncer) Run(ctx context.Context) (*Result, error) {
+	if s.URLs == nil {
+		return nil, errors.New("syncer: URLs is required")
+	}
+	files, err := s.Scan()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Synced: make(map[string]*slab.Post), Failed: make(map[string]error)}
+	topics := s.Client.Topic.NewResolver() // to list the topics only once
+	present := make(map[string]bool)
+	for _, f := range files {
+		present[f.ExternalID] = true
+		content, err := ioutil.ReadFile(filepath.Join(s.Root, filepath.FromSlash(f.Path)))
+		if err != nil {
+			res.Failed[f.ExternalID] = err
+			s.logf("failed to read %s: %v", f.Path, err)
+			continue
+		}
+		hash := hashContent(content)
+		editURL, readURL := s.urls(f)
+		if s.unchanged(f, hash, editURL, readURL) {
+			drifted, err := s.drifted(ctx, f)
+			if err != nil {
+				res.Failed[f.ExternalID] = err
+				s.logf("failed to check %s for drift: %v", f.Path, err)
+				continue
+			}
+			if !drifted {
+				res.Skipped = append(res.Skipped, f.ExternalID)
+				continue
+			}
+			res.Drifted = append(res.Drifted, f.ExternalID)
+			s.logf("post of %s changed on slab, syncing it again", f.Path)
+		}
+
+		p, topicIDs, err := s.syncFile(ctx, f, content, editURL, readURL, topics)
+		if err != nil {
+			res.Failed[f.ExternalID] = err
+			s.logf("failed to sync %s: %v", f.Path, err)
+			continue
+		}
+		if err := s.detachTopics(ctx, f, p.ID, topicIDs); err != nil {
+			res.Failed[f.ExternalID] = err
+			s.logf("failed to sync %s: %v", f.Path, err)
+			continue
+		}
+		res.Synced[f.ExternalID] = p
+		if s.Manifest != nil {
+			s.Manifest.Entries[f.ExternalID] = &ManifestEntry{
+				Path: f.Path, Hash: hash, Topics: f.Topics, TopicIDs: topicIDs, EditURL: editURL, ReadURL: readURL,
+				PostID: p.ID, Version: p.Version, SyncedAt: time.Now().UTC(),
+			}
+		}
+		s.logf("synced %s to post %s", f.Path, p.ID)
+	}
+
+	for _, id := range s.knownExternalIDs() {
+		if present[id] || !s.owned(id) {
+			continue
+		}
+		if _, err := s.Client.Post.DeleteContext(ctx, "", id); err != nil {
+			res.Failed[id] = err
+			s.logf("failed to delete %s: %v", id, err)
+			continue
+		}
+		if s.Manifest != nil {
+			delete(s.Manifest.Entries, id)
+		}
+		res.Deleted = append(res.Deleted, id)
+		s.logf("deleted %s", id)
+	}
+
+	if len(res.Failed) > 0 {
+		return res, fmt.Errorf("syncer: %d document(s) failed to sync", len(res.Failed))
+	}
+	return res, nil
+}
+
+// unchanged tells whether the file has the same content, topics and urls as when it was recorded in the manifest.
+func (s *Syncer) unchanged(f File, hash, editURL, readURL string) bool {
+	if s.Manifest == nil {
+		return false
+	}
+	e, ok := s.Manifest.Entries[f.ExternalID]
+	return ok && e.PostID != "" && e.Hash == hash && reflect.DeepEqual(e.Topics, f.Topics) &&
+		e.EditURL == editURL && e.ReadURL == readURL
+}
+
+// owned tells whether the post of a known externalID was synced from this directory, and may thus be deleted.
+func (s *Syncer) owned(id string) bool {
+	if s.Manifest != nil && s.Manifest.Entries[id] != nil {
+		return true
+	}
+	return strings.HasPrefix(id, s.Prefix)
+}
+
+// urls returns the edit and read urls of the file, from its front matter or else from URLs.
+func (s *Syncer) urls(f File) (editURL, readURL string) {
+	editURL, readURL = s.URLs(f.Path)
+	if f.EditURL != "" {
+		editURL = f.EditURL
+	}
+	if f.ReadURL != "" {
+		readURL = f.ReadURL
+	}
+	return editURL, readURL
+}
+
+// drifted tells whether the post of an unchanged file was modified or deleted on slab since it was synced.
+func (s *Syncer) drifted(ctx context.Context, f File) (bool, error) {
+	if !s.DetectDrift {
+		return false, nil
+	}
+	e := s.Manifest.Entries[f.ExternalID]
+	p, err := s.Client.Post.GetContext(ctx, e.PostID)
+	if slab.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return p == nil || p.Version != e.Version, nil
+}
+
+// knownExternalIDs returns KnownExternalIDs and the externalIDs of the manifest, without duplicates.
+func (s *Syncer) knownExternalIDs() []string {
+	ids := append([]string(nil), s.KnownExternalIDs...)
+	if s.Manifest != nil {
+		ids = append(ids, s.Manifest.ExternalIDs()...)
+	}
+	seen := make(map[string]bool, len(ids))
+	known := ids[:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			known = append(known, id)
+		}
+	}
+	return known
+}
+
+// syncFile syncs the file and attaches its post to its topics, whose ids are returned.
+func (s *Syncer) syncFile(ctx context.Context, f File, content []byte, editURL, readURL string,
+	topics *slab.TopicResolver) (*slab.Post, []string, error) {
+	if f.Format == "MARKDOWN" {
+		_, body, err := ParseFrontMatter(content)
+		if err != nil {
+			return nil, nil, err
+		}
+		content = body
+		if f.Title != "" {
+			content = withTitle(content, f.Title)
+		}
+	}
+	p, err := s.Client.Post.SyncContext(ctx, f.ExternalID, string(content), editURL, readURL, f.Format)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p == nil {
+		return nil, nil, errors.New("syncer: the synced post was not returned")
+	}
+
+	topicIDs := make([]string, 0, len(f.Topics))
+	for _, topic := range f.Topics {
+		topicID, err := topics.Resolve(ctx, topic, "/")
+		if err != nil {
+			return nil, nil, fmt.Errorf("generating topic %q: %w", topic, err)
+		}
+		if _, err := s.Client.Topic.AddToPostContext(ctx, topicID, p.ID); err != nil {
+			return nil, nil, fmt.Errorf("adding post to topic %q: %w", topic, err)
+		}
+		topicIDs = append(topicIDs, topicID)
+	}
+
+	if f.Publish != nil && *f.Publish != (p.PublishedAt != nil) {
+		id := p.ID
+		if p, err = s.Client.Post.UpdateContext(ctx, id, slab.UpdatePostOptions{Published: f.Publish}); err != nil {
+			return nil, nil, fmt.Errorf("publishing post: %w", err)
+		}
+		if p == nil {
+			return nil, nil, fmt.Errorf("syncer: the published post %s was not returned", id)
+		}
+	}
+	return p, topicIDs, nil
+}
+
+// detachTopics removes the post from the topics recorded in the Manifest which are not among topicIDs anymore.
+// The topics deleted on slab in the meantime are ignored.
+func (s *Syncer) detachTopics(ctx context.Context, f File, postID string, topicIDs []string) error {
+	if s.Manifest == nil || s.Manifest.Entries[f.ExternalID] == nil {
+		return nil
+	}
+	current := make(map[string]bool, len(topicIDs))
+	for _, id := range topicIDs {
+		current[id] = true
+	}
+	for _, id := range s.Manifest.Entries[f.ExternalID].TopicIDs {
+		if current[id] {
+			continue
+		}
+		if _, err := s.Client.Topic.RemoveFromPostContext(ctx, id, postID); err != nil && !slab.IsNotFound(err) {
+			return fmt.Errorf("removing post from topic %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) logf(format string, args ...interface{}) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
@@ -0,0 +1,299 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/VEVO/slab-go/internal/slabtest"
+	"github.com/VEVO/slab-go/slab"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTree creates the given files, relative to a new temporary directory which is returned.
+func writeTree(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "syncer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func testURLs(relPath string) (string, string) {
+	return "https://example.com/edit/" + relPath, "https://example.com/blob/" + relPath
+}
+
+func TestSyncer_Scan(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"README.md":               "# Readme",
+		"guides/setup.markdown":   "# Setup",
+		"guides/deploy/prod.HTML": "<h1>Prod</h1>",
+		"image.png":               "not a doc",
+		".github/template.md":     "hidden",
+		"guides/.draft.md":        "hidden",
+	})
+	defer os.RemoveAll(dir)
+
+	s := &Syncer{Root: dir, Prefix: "repo:", Topic: "Engineering/Repo"}
+	got, err := s.Scan()
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	want := []File{
+		{Path: "README.md", ExternalID: "repo:README.md", Format: "MARKDOWN", Topics: []string{"Engineering/Repo"}},
+		{Path: "guides/deploy/prod.HTML", ExternalID: "repo:guides/deploy/prod.HTML", Format: "HTML", Topics: []string{"Engineering/Repo/guides/deploy"}},
+		{Path: "guides/setup.markdown", ExternalID: "repo:guides/setup.markdown", Format: "MARKDOWN", Topics: []string{"Engineering/Repo/guides"}},
+	}
+	assert.Equal(t, want, got)
+
+	s.Topic = ""
+	got, err = s.Scan()
+	assert.NoError(t, err)
+	assert.Empty(t, got[0].Topics)
+	assert.Equal(t, []string{"guides/deploy"}, got[1].Topics)
+}
+
+func TestSyncer_Run(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+	engID := srv.AddTopic("Engineering", "")
+
+	dir := writeTree(t, map[string]string{
+		"README.md":          "# Readme",
+		"guides/setup.md":    "# Setup",
+		"guides/deploy.md":   "# Deploy",
+		"api/reference.html": "<h1>Reference</h1>",
+	})
+	defer os.RemoveAll(dir)
+
+	var logs []string
+	s := &Syncer{
+		Client: srv.Client(), Root: dir, Prefix: "repo:", Topic: "engineering/Repo", URLs: testURLs,
+		Logf: func(format string, args ...interface{}) { logs = append(logs, format) },
+	}
+	res, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Len(t, res.Synced, 4)
+	assert.Empty(t, res.Deleted)
+	assert.Empty(t, res.Failed)
+	assert.Len(t, logs, 4)
+
+	setup := srv.PostByExternalID("repo:guides/setup.md")
+	if assert.NotNil(t, setup) {
+		assert.Equal(t, "# Setup", setup.Content)
+		assert.Equal(t, "MARKDOWN", setup.Format)
+		assert.Equal(t, "https://example.com/edit/guides/setup.md", setup.EditURL)
+		assert.Equal(t, "https://example.com/blob/guides/setup.md", setup.ReadURL)
+		if assert.Len(t, setup.Topics, 1) {
+			// The existing Engineering topic is reused, as AutoGenerate ignores the case
+			assert.Equal(t, "Engineering/Repo/guides", srv.TopicPath(setup.Topics[0]))
+		}
+	}
+	ref := srv.PostByExternalID("repo:api/reference.html")
+	if assert.NotNil(t, ref) {
+		assert.Equal(t, "HTML", ref.Format)
+		assert.Equal(t, "Engineering/Repo/api", srv.TopicPath(ref.Topics[0]))
+	}
+	readme := srv.PostByExternalID("repo:README.md")
+	if assert.NotNil(t, readme) {
+		assert.Equal(t, "Engineering/Repo", srv.TopicPath(readme.Topics[0]))
+	}
+	// Engineering, Repo, guides and api: each topic is generated once
+	assert.Len(t, srv.Topics, 4)
+	assert.Equal(t, engID, srv.Topics[engID].ID)
+	// The topics are listed once for the whole run
+	assert.Equal(t, 1, srv.Calls["organization"])
+}
+
+func TestSyncer_Run_Deletes(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	dir := writeTree(t, map[string]string{"kept.md": "# Kept"})
+	defer os.RemoveAll(dir)
+	srv.AddPost(slabtest.Post{ExternalID: "repo:kept.md", Content: "# Kept"})
+	srv.AddPost(slabtest.Post{ExternalID: "repo:gone.md", Content: "# Gone"})
+	srv.AddPost(slabtest.Post{ExternalID: "other:gone.md", Content: "# Not ours"})
+
+	s := &Syncer{
+		Client: srv.Client(), Root: dir, Prefix: "repo:", URLs: testURLs,
+		KnownExternalIDs: []string{"repo:kept.md", "repo:gone.md", "other:gone.md"},
+	}
+	res, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Equal(t, []string{"repo:gone.md"}, res.Deleted)
+	assert.Nil(t, srv.PostByExternalID("repo:gone.md"))
+	assert.NotNil(t, srv.PostByExternalID("other:gone.md"))
+	assert.NotNil(t, srv.PostByExternalID("repo:kept.md"))
+	assert.Empty(t, srv.PostByExternalID("repo:kept.md").Topics)
+}
+
+func TestSyncer_Run_Failures(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	dir := writeTree(t, map[string]string{"a.md": "# A", "b.md": "# B"})
+	defer os.RemoveAll(dir)
+
+	s := &Syncer{
+		Client: srv.Client(), Root: dir, URLs: testURLs,
+		KnownExternalIDs: []string{"never-synced.md"},
+	}
+	res, err := s.Run(context.Background())
+	assert.Error(t, err)
+	assert.Len(t, res.Synced, 2)
+	var failed []string
+	for id := range res.Failed {
+		failed = append(failed, id)
+	}
+	sort.Strings(failed)
+	assert.Equal(t, []string{"never-synced.md"}, failed)
+
+	_, err = (&Syncer{Client: srv.Client(), Root: dir}).Run(context.Background())
+	assert.Error(t, err)
+}
+
+func TestSyncer_Run_NullPost(t *testing.T) {
+	// syncPost answers null for a.md, and updatePost answers null when publishing b.md
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		resp := `{"data":{"updatePost":null}}`
+		switch req.Variables["externalId"] {
+		case "a.md":
+			resp = `{"data":{"syncPost":null}}`
+		case "b.md", "c.md":
+			resp = `{"data":{"syncPost":{"id":"` + req.Variables["externalId"].(string) + `","version":1}}}`
+		}
+		_, _ = io.WriteString(w, resp)
+	}))
+	defer srv.Close()
+
+	dir := writeTree(t, map[string]string{"a.md": "# A", "b.md": "---\npublish: true\n---\n# B", "c.md": "# C"})
+	defer os.RemoveAll(dir)
+
+	m := NewManifest()
+	s := &Syncer{Client: slab.NewClient(nil, "dummy_token", slab.WithEndpoint(srv.URL)), Root: dir, URLs: testURLs, Manifest: m}
+	res, err := s.Run(context.Background())
+	assert.Error(t, err)
+	if assert.Len(t, res.Failed, 2) {
+		assert.EqualError(t, res.Failed["a.md"], "syncer: the synced post was not returned")
+		assert.EqualError(t, res.Failed["b.md"], "syncer: the published post b.md was not returned")
+	}
+	assert.Equal(t, []string{"c.md"}, m.ExternalIDs(), "the other files are still synced")
+}
+
+func TestSyncer_Run_Manifest(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	dir := writeTree(t, map[string]string{"a.md": "# A", "docs/b.md": "# B", "c.md": "# C"})
+	defer os.RemoveAll(dir)
+
+	m := NewManifest()
+	s := &Syncer{Client: srv.Client(), Root: dir, Prefix: "repo:", URLs: testURLs, Manifest: m}
+	res, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+	assert.Len(t, res.Synced, 3)
+	assert.Equal(t, []string{"repo:a.md", "repo:c.md", "repo:docs/b.md"}, m.ExternalIDs())
+	b := m.Entries["repo:docs/b.md"]
+	assert.Equal(t, "docs/b.md", b.Path)
+	assert.Equal(t, hashContent([]byte("# B")), b.Hash)
+	assert.Equal(t, []string{"docs"}, b.Topics)
+	assert.Equal(t, srv.PostByExternalID("repo:docs/b.md").ID, b.PostID)
+	assert.False(t, b.SyncedAt.IsZero())
+
+	// Nothing changed: no mutation is sent
+	syncs := srv.Calls["syncPost"]
+	res, err = s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, res.Synced)
+	assert.Equal(t, []string{"repo:a.md", "repo:c.md", "repo:docs/b.md"}, res.Skipped)
+	assert.Equal(t, syncs, srv.Calls["syncPost"])
+
+	// Only the modified file is synced and the removed one is deleted thanks to the manifest
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.md"), []byte("# A2"), 0644))
+	assert.NoError(t, os.Remove(filepath.Join(dir, "c.md")))
+	res, err = s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, res.Synced, 1)
+	assert.NotNil(t, res.Synced["repo:a.md"])
+	assert.Equal(t, []string{"repo:docs/b.md"}, res.Skipped)
+	assert.Equal(t, []string{"repo:c.md"}, res.Deleted)
+	assert.Equal(t, []string{"repo:a.md", "repo:docs/b.md"}, m.ExternalIDs())
+	assert.Equal(t, 1, m.Entries["repo:a.md"].Version)
+	assert.Nil(t, srv.PostByExternalID("repo:c.md"))
+}
+
+func TestSyncer_Run_DetectDrift(t *testing.T) {
+	srv := slabtest.NewServer()
+	defer srv.Close()
+
+	dir := writeTree(t, map[string]string{"a.md": "# A", "b.md": "# B", "c.md": "# C"})
+	defer os.RemoveAll(dir)
+
+	m := NewManifest()
+	s := &Syncer{Client: srv.Client(), Root: dir, URLs: testURLs, Manifest: m, DetectDrift: true}
+	_, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expecting no error, got: %v", err)
+	}
+
+	// a.md is edited on slab, c.md is deleted on slab
+	srv.Mu.Lock()
+	srv.Posts[m.Entries["a.md"].PostID].Version++
+	srv.Posts[m.Entries["a.md"].PostID].Content = "# Edited on slab"
+	delete(srv.Posts, m.Entries["c.md"].PostID)
+	srv.Mu.Unlock()
+
+	res, err := s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.md", "c.md"}, res.Drifted)
+	assert.Equal(t, []string{"b.md"}, res.Skipped)
+	assert.Len(t, res.Synced, 2)
+	assert.Equal(t, "# A", srv.PostByExternalID("a.md").Content)
+	assert.Equal(t, 2, m.Entries["a.md"].Version)
+	assert.NotNil(t, srv.PostByExternalID("c.md"))
+	assert.Equal(t, srv.PostByExternalID("c.md").ID, m.Entries["c.md"].PostID)
+
+	// Without drift detection, the manifest is trusted
+	srv.Mu.Lock()
+	srv.Posts[m.Entries["b.md"].PostID].Version++
+	srv.Mu.Unlock()
+	s.DetectDrift = false
+	res, err = s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, res.Drifted)
+	assert.Len(t, res.Skipped, 3)
+}
+
+func TestSyncer_topicOf(t *testing.T) {
+	s := &Syncer{Topic: `Engineering/CI\/CD`}
+	assert.Equal(t, `Engineering/CI\/CD`, s.topicOf("a.md"))
+	assert.Equal(t, `Engineering/CI\/CD/back\\slash/docs`, s.topicOf(`back\slash/docs/a.md`))
+}